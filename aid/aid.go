@@ -2,7 +2,10 @@ package aid
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"github.com/patroclos/go-conq"
@@ -12,17 +15,21 @@ var DefaultHelp conq.Helper = basicHelper{}
 
 type basicHelper struct{}
 
-func (basicHelper) Help(sub conq.HelpSubject) (help string) {
+// Usage returns the usage line for the command at the end of pth, consisting
+// of the command names followed by the options and positional arguments.
+func Usage(pth conq.Pth) string {
 	var b strings.Builder
-	defer func() {
-		help = b.String()
-	}()
-
-	fmt.Fprintf(&b, "usage: %s", sub.Cmd.Name)
-	if len(sub.Cmd.Opts) > 0 {
+	for i, x := range pth {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(x.Name)
+	}
+	cmd := pth[len(pth)-1]
+	if len(cmd.Opts) > 0 {
 		fmt.Fprint(&b, " [options]")
 	}
-	for _, arg := range sub.Cmd.Args {
+	for _, arg := range cmd.Args {
 		o := arg.Opt()
 		switch o.Require {
 		case true:
@@ -31,9 +38,27 @@ func (basicHelper) Help(sub conq.HelpSubject) (help string) {
 			fmt.Fprintf(&b, " [%s]", o.Name)
 		}
 	}
-	b.WriteString("\n\n")
+	return b.String()
+}
 
+func (basicHelper) Help(sub conq.HelpSubject) (help string) {
+	var b strings.Builder
+	defer func() {
+		help = b.String()
+	}()
+
+	width := Width(sub.Ctx)
 	headlineStyle := color.New(color.Bold, color.Underline)
+	if ColorEnabled(sub.Ctx) {
+		headlineStyle.EnableColor()
+	} else {
+		headlineStyle.DisableColor()
+	}
+
+	fmt.Fprintf(&b, "usage: %s\n\n", Usage(conq.Pth{sub.Cmd}))
+	if s := sub.Cmd.Help.Summary; s != "" {
+		fmt.Fprintf(&b, "%s\n\n", Wrap(s, width, 0))
+	}
 
 	if len(sub.Cmd.Opts) > 0 {
 		var sorted []conq.Opter
@@ -45,86 +70,152 @@ func (basicHelper) Help(sub conq.HelpSubject) (help string) {
 			}
 			sorted = append(sorted, opt)
 		}
-		sorted = append(required, sorted...)
 		// required options sorted to top
+		sorted = append(required, sorted...)
 		headlineStyle.Fprint(&b, "Options:\n")
-		var longest int
-		for _, opt := range sorted {
-			o := opt.Opt()
-			if l := len(o.Type.Name()); longest < l {
-				longest = l
-			}
-		}
-		format := fmt.Sprintf("%%%dv  ", -longest)
-		for _, opt := range sorted {
-			o := opt.Opt()
-			if o.Type != nil {
-				fmt.Fprintf(&b, format, o.Type.Name())
-			}
-			switch o.Require {
-			case true:
-				fmt.Fprintf(&b, "%s (required)\n", o.Name)
-			case false:
-				fmt.Fprintf(&b, "%s\n", o.Name)
-			}
-		}
+		b.WriteString(ParamTable(sorted, width, "(required)", ""))
 	}
 
 	if len(sub.Cmd.Args) > 0 {
-
 		headlineStyle.Fprint(&b, "\nArguments:\n")
-		var longest int
-		for _, arg := range sub.Cmd.Args {
-			if l := len(arg.Opt().Name); l > longest {
-				longest = l
-			}
+		b.WriteString(ParamTable(sub.Cmd.Args, width, "", "(optional)"))
+	}
+
+	var visible []string
+	for _, c := range sub.Cmd.Commands {
+		if !c.Help.Hidden {
+			visible = append(visible, c.Name)
 		}
-		format := fmt.Sprintf("%%%dv  ", -longest)
-		for _, arg := range sub.Cmd.Args {
-			o := arg.Opt()
-			if o.Type != nil {
-				fmt.Fprintf(&b, format, o.Type.Name())
-			}
-			switch o.Require {
-			case true:
-				fmt.Fprintf(&b, "%s\n", o.Name)
-			case false:
-				fmt.Fprintf(&b, "%s (optional)\n", o.Name)
-			}
+	}
+	if len(visible) > 0 {
+		headlineStyle.Fprint(&b, "\nCommands")
+		fmt.Fprintf(&b, ": %s\n", Wrap(strings.Join(visible, ", "), width, len("Commands: ")))
+	}
+
+	if len(sub.Cmd.Env) > 0 {
+		headlineStyle.Fprint(&b, "\nEnvironment Variables:\n")
+		b.WriteString(ParamTable(sub.Cmd.Env, width, "(required)", ""))
+	}
+
+	return
+}
+
+// ParamTable renders a table of parameters with their type, name and
+// description, wrapping the descriptions to width with a hanging indent.
+// The names of required and optional parameters are suffixed with the given
+// labels, unless they are empty.
+func ParamTable(opts conq.Opts, width int, required, optional string) string {
+	var b strings.Builder
+	type row struct{ typ, name, desc string }
+	rows := make([]row, len(opts))
+	var typeW, nameW int
+	for i, opt := range opts {
+		o := opt.Opt()
+		r := row{name: o.Name, desc: o.Description}
+		if o.Type != nil {
+			r.typ = o.Type.Name()
 		}
+		switch {
+		case o.Require && required != "":
+			r.name = fmt.Sprintf("%s %s", r.name, required)
+		case !o.Require && optional != "":
+			r.name = fmt.Sprintf("%s %s", r.name, optional)
+		}
+		if l := len(r.typ); l > typeW {
+			typeW = l
+		}
+		if l := len(r.name); l > nameW {
+			nameW = l
+		}
+		rows[i] = r
 	}
 
-	if len(sub.Cmd.Commands) > 0 {
-		headlineStyle.Fprint(&b, "\nCommands")
-		fmt.Fprintf(&b, ": %s", sub.Cmd.Commands[0].Name)
-		for _, c := range sub.Cmd.Commands[1:] {
-			fmt.Fprintf(&b, ", %s", c.Name)
+	for _, r := range rows {
+		if typeW > 0 {
+			fmt.Fprintf(&b, "%-*s  ", typeW, r.typ)
 		}
-		b.WriteString("\n")
+		if r.desc == "" {
+			fmt.Fprintf(&b, "%s\n", r.name)
+			continue
+		}
+		indent := typeW + 2 + nameW + 2
+		if width-indent < 20 {
+			// too narrow for a description column, continue on the next line
+			indent = typeW + 6
+			fmt.Fprintf(&b, "%s\n%s%s\n", r.name, strings.Repeat(" ", indent), Wrap(r.desc, width, indent))
+			continue
+		}
+		fmt.Fprintf(&b, "%-*s  %s\n", nameW, r.name, Wrap(r.desc, width, indent))
 	}
+	return b.String()
+}
 
-	if len(sub.Cmd.Env) > 0 {
-		fmt.Fprintf(&b, "\nEnvironment Variables:\n")
-		var longest int
-		for _, arg := range sub.Cmd.Env {
-			if l := len(arg.Opt().Name); l > longest {
-				longest = l
-			}
+// Width returns the width help should be wrapped to when written to c.Out,
+// defaulting to 80 columns.
+func Width(c *conq.Ctx) int {
+	if c != nil {
+		if w, _, ok := conq.TermSize(c.Out); ok {
+			return w
 		}
-		format := fmt.Sprintf("%%%dv  ", -longest)
-		for _, arg := range sub.Cmd.Env {
-			o := arg.Opt()
-			if o.Type != nil {
-				fmt.Fprintf(&b, format, o.Type.Name())
-			}
-			switch o.Require {
-			case true:
-				fmt.Fprintf(&b, "%s (required)\n", o.Name)
-			case false:
-				fmt.Fprintf(&b, "%s\n", o.Name)
+	}
+	return 80
+}
+
+// ColorEnabled reports whether help written to c.Out should be colored,
+// according to the conq.ColorMode of c.Com.
+func ColorEnabled(c *conq.Ctx) bool {
+	if c == nil {
+		return !color.NoColor
+	}
+	mode := conq.ColorAuto
+	if cm, ok := c.Com.(interface{ ColorMode() conq.ColorMode }); ok {
+		mode = cm.ColorMode()
+	}
+	return mode.Enabled(c.Out)
+}
+
+// Wrap breaks s into lines of at most width columns, at spaces.  The first line
+// is assumed to start at column indent, following lines are indented by indent.
+// Words longer than the available space are not broken.
+func Wrap(s string, width, indent int) string {
+	avail := width - indent
+	var b strings.Builder
+	for i, para := range strings.Split(s, "\n") {
+		if i > 0 {
+			fmt.Fprintf(&b, "\n%s", strings.Repeat(" ", indent))
+		}
+		col := 0
+		for _, word := range strings.Fields(para) {
+			switch {
+			case col == 0:
+			case col+1+utf8.RuneCountInString(word) > avail:
+				fmt.Fprintf(&b, "\n%s", strings.Repeat(" ", indent))
+				col = 0
+			default:
+				b.WriteString(" ")
+				col++
 			}
+			b.WriteString(word)
+			col += utf8.RuneCountInString(word)
 		}
 	}
+	return b.String()
+}
 
-	return
+// WriteFile creates the file at path and writes it using write.  Errors closing
+// the file are returned as well, as they may mean it wasn't written completely.
+// What names the kind of file in errors, like "man page".
+func WriteFile(path, what string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed creating %s: %w", what, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed writing %s %s: %w", what, path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed writing %s %s: %w", what, path, err)
+	}
+	return nil
 }
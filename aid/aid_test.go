@@ -0,0 +1,75 @@
+package aid_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid"
+	"github.com/patroclos/go-conq/aid/cmdhelp"
+	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/getopt"
+)
+
+func TestWrap(t *testing.T) {
+	got := aid.Wrap("the quick brown fox jumps over the lazy dog", 20, 4)
+	expect := "the quick brown\n    fox jumps over\n    the lazy dog"
+	if got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+
+	got = aid.Wrap("über straße größe", 12, 0)
+	expect = "über straße\ngröße"
+	if got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+}
+
+func TestHelpWrapsDescriptions(t *testing.T) {
+	t.Setenv("COLUMNS", "40")
+	root := &conq.Cmd{
+		Name:     "app",
+		Opts:     conq.Opts{conq.Opt[int]{Name: "depth", Description: "how deep the app is supposed to dig"}},
+		Commands: []*conq.Cmd{cmdhelp.New(nil)},
+	}
+
+	var out bytes.Buffer
+	ctx := conq.OSContext("help")
+	ctx.Out = &out
+	if err := commander.New(getopt.New(), aid.DefaultHelp).Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "Options:\nint  depth  how deep the app is supposed\n            to dig\n"
+	if !strings.Contains(out.String(), expect) {
+		t.Errorf("expected help to contain\n%s\ngot\n%s", expect, out.String())
+	}
+}
+
+func TestColorFlag(t *testing.T) {
+	root := &conq.Cmd{
+		Name:     "app",
+		Opts:     conq.Opts{conq.Opt[int]{Name: "depth"}},
+		Commands: []*conq.Cmd{cmdhelp.New(nil)},
+	}
+
+	for mode, colored := range map[string]bool{"always": true, "never": false, "auto": false} {
+		var out bytes.Buffer
+		ctx := conq.OSContext("--color="+mode, "help")
+		ctx.Out = &out
+		if err := commander.New(getopt.New(), aid.DefaultHelp).Execute(root, ctx); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(out.String(), "\033[") != colored {
+			t.Errorf("--color=%s: expected colored=%v, got %q", mode, colored, out.String())
+		}
+	}
+
+	ctx := conq.OSContext("--color=foo", "help")
+	ctx.Out = &bytes.Buffer{}
+	err := commander.New(getopt.New(), aid.DefaultHelp).Execute(root, ctx)
+	if err == nil || err.Error() != `invalid --color option: invalid color mode "foo", expected auto, always or never` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package cmdhelp
+
+import (
+	"io/fs"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/tree"
+	"github.com/posener/complete"
+)
+
+// subjectPredictor completes the commands and help topics accepted as
+// arguments to the help command.
+type subjectPredictor struct {
+	helpdir fs.FS
+}
+
+func (p subjectPredictor) Predict(a complete.Args) []string {
+	return p.PredictCtx(conq.Ctx{Path: conq.Pth{{}}}, a)
+}
+
+func (p subjectPredictor) PredictCtx(c conq.Ctx, a complete.Args) (names []string) {
+	var words []string
+	for _, w := range a.Completed {
+		if !strings.HasPrefix(w, "-") {
+			words = append(words, w)
+		}
+	}
+
+	if pth := tree.Find(c.Path[0], words...); pth != nil {
+		for _, sub := range pth[len(pth)-1].Commands {
+			if !sub.Help.Hidden {
+				names = append(names, sub.Name)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+a:
+	for _, topic := range Topics(p.helpdir, c) {
+		parts := strings.Fields(topic)
+		if len(parts) <= len(words) {
+			continue
+		}
+		for i, w := range words {
+			if parts[i] != w {
+				continue a
+			}
+		}
+		if next := parts[len(words)]; !seen[next] {
+			seen[next] = true
+			names = append(names, next)
+		}
+	}
+	return
+}
@@ -0,0 +1,179 @@
+package cmdhelp
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid"
+	"github.com/patroclos/go-conq/tree"
+)
+
+// partials are the builtin templates available to all help-templates:
+// "usage", "options", "arguments", "environment" and "commands", each
+// rendering the respective part of the HelpContext.Cmd's help, and "topics"
+// listing the available help topics.
+//
+//go:embed partials.tmpl
+var partials string
+
+// HelpContext is the input for help-templates.  When rendering the help for a
+// command, the command is at the end of it's Path.
+type HelpContext conq.Ctx
+
+func (c HelpContext) Root() *conq.Cmd {
+	return c.Path[0]
+}
+
+func (c HelpContext) Cmd() *conq.Cmd {
+	return c.Path[len(c.Path)-1]
+}
+
+// Width is the width helptexts are wrapped to.
+func (c HelpContext) Width() int {
+	ctx := conq.Ctx(c)
+	return aid.Width(&ctx)
+}
+
+// Usage is the usage line of Cmd.
+func (c HelpContext) Usage() string {
+	return aid.Usage(c.Path)
+}
+
+// Options is the table of Cmd's options.
+func (c HelpContext) Options() string {
+	return aid.ParamTable(c.Cmd().Opts, c.Width(), "(required)", "")
+}
+
+// Arguments is the table of Cmd's positional arguments.
+func (c HelpContext) Arguments() string {
+	return aid.ParamTable(c.Cmd().Args, c.Width(), "", "(optional)")
+}
+
+// Environment is the table of Cmd's environment variables.
+func (c HelpContext) Environment() string {
+	return aid.ParamTable(c.Cmd().Env, c.Width(), "(required)", "")
+}
+
+// Subcommands are the commands of Cmd that aren't hidden.
+func (c HelpContext) Subcommands() (subs []*conq.Cmd) {
+	for _, sub := range c.Cmd().Commands {
+		if !sub.Help.Hidden {
+			subs = append(subs, sub)
+		}
+	}
+	return
+}
+
+// CommandList is a table of the Subcommands with their summaries.
+func (c HelpContext) CommandList() string {
+	subs := c.Subcommands()
+	var nameW int
+	for _, sub := range subs {
+		if l := len(sub.Name); l > nameW {
+			nameW = l
+		}
+	}
+	var b strings.Builder
+	for _, sub := range subs {
+		if sub.Help.Summary == "" {
+			fmt.Fprintf(&b, "  %s\n", sub.Name)
+			continue
+		}
+		indent := 2 + nameW + 2
+		fmt.Fprintf(&b, "  %-*s  %s\n", nameW, sub.Name, aid.Wrap(sub.Help.Summary, c.Width(), indent))
+	}
+	return b.String()
+}
+
+// Link refers to the command reached by following the named subcommands from
+// the root.  It fails for unknown commands, so templates can't refer to
+// commands that don't exist.
+func (c HelpContext) Link(names ...string) (string, error) {
+	pth := tree.Find(c.Root(), names...)
+	if pth == nil {
+		return "", fmt.Errorf("link to unknown command %q", strings.Join(names, " "))
+	}
+	ctx := conq.Ctx(c)
+	return style(&ctx, color.Bold)(strings.Join(append([]string{c.Root().Name}, names...), " ")), nil
+}
+
+// T translates and formats a message using the Ctx.Printer.
+func (c HelpContext) T(key string, args ...any) string {
+	if c.Printer == nil {
+		return fmt.Sprintf(key, args...)
+	}
+	return c.Printer.Sprintf(key, args...)
+}
+
+var colors = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+	"black":     color.FgBlack,
+	"red":       color.FgRed,
+	"green":     color.FgGreen,
+	"yellow":    color.FgYellow,
+	"blue":      color.FgBlue,
+	"magenta":   color.FgMagenta,
+	"cyan":      color.FgCyan,
+	"white":     color.FgWhite,
+}
+
+// funcs available in help-templates:
+//
+//	wrap WIDTH INDENT TEXT   wrap TEXT with a hanging indent (see aid.Wrap)
+//	indent N TEXT            indent every line of TEXT by N spaces
+//	bold TEXT                bold TEXT, if output is colored
+//	color NAMES TEXT         style TEXT with the comma-separated NAMES of
+//	                         colors and attributes, if output is colored
+//	tr KEY ARGS...           translate using the Ctx.Printer
+//	topics                   names of the available help topics
+func funcs(c conq.Ctx) template.FuncMap {
+	return template.FuncMap{
+		"wrap": func(width, indent int, s string) string {
+			return aid.Wrap(s, width, indent)
+		},
+		"indent": func(n int, s string) string {
+			pad := strings.Repeat(" ", n)
+			lines := strings.Split(s, "\n")
+			for i, l := range lines {
+				if l != "" {
+					lines[i] = pad + l
+				}
+			}
+			return strings.Join(lines, "\n")
+		},
+		"bold": style(&c, color.Bold),
+		"color": func(names, s string) (string, error) {
+			var attrs []color.Attribute
+			for _, name := range strings.Split(names, ",") {
+				attr, ok := colors[strings.TrimSpace(name)]
+				if !ok {
+					return "", fmt.Errorf("unknown color %q", name)
+				}
+				attrs = append(attrs, attr)
+			}
+			return style(&c, attrs...)(s), nil
+		},
+		"tr": HelpContext(c).T,
+	}
+}
+
+// style returns a func applying attrs to it's argument, if output to c is to be
+// colored.
+func style(c *conq.Ctx, attrs ...color.Attribute) func(string) string {
+	st := color.New(attrs...)
+	if aid.ColorEnabled(c) {
+		st.EnableColor()
+	} else {
+		st.DisableColor()
+	}
+	return func(s string) string {
+		return st.Sprint(s)
+	}
+}
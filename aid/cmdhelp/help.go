@@ -1,93 +1,413 @@
 package cmdhelp
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid"
+	"github.com/patroclos/go-conq/tree"
+	"golang.org/x/text/language"
 )
 
-func New(helpdir fs.FS) *conq.Cmd {
+var (
+	optAll     = conq.Opt[bool]{Name: "all,a", Description: "render the help for every command of the (sub)tree"}
+	optNoPager = conq.Opt[bool]{Name: "no-pager", Description: "don't pipe long helptexts through a pager"}
+	optSearch  = conq.Opt[string]{Name: "search,s", Description: "search commands, options, articles and topics"}
+)
+
+// Option configures the help command created by New.
+type Option func(*config)
+
+type config struct {
+	pager Pager
+}
+
+// WithPager replaces the SystemPager used to display helptexts.  A nil Pager
+// writes helptexts to Ctx.Out directly.
+func WithPager(p Pager) Option {
+	return func(c *config) {
+		c.pager = p
+	}
+}
+
+// New creates a help command.  Templates found in helpdir and the
+// CmdHelp.Articles of the tree take precedence over the commanders Helper.
+// Templates in the "topics" directory are help topics, that can be requested
+// like commands and are listed in the help index.
+// With --all, the help for every command in the (sub)tree is rendered into a
+// single document, with --search, the matching commands and topics are listed.
+// Output is displayed using the SystemPager, unless
+// configured otherwise or --no-pager is given.
+func New(helpdir fs.FS, opts ...Option) *conq.Cmd {
+	cfg := config{pager: SystemPager}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	argSubject := conq.Opt[string]{
+		Name:        "subject",
+		Description: "command or help topic",
+		Predict:     subjectPredictor{helpdir},
+	}
 	return &conq.Cmd{
 		Name: "help",
+		Opts: conq.Opts{optAll, optNoPager, optSearch},
+		Args: conq.Opts{argSubject},
+		Help: conq.CmdHelp{Summary: "show help for a command or topic"},
 		Run: func(c conq.Ctx) error {
-			subj := conq.HelpSubject{Cmd: c.Path[0]}
-			if helpdir != nil {
-				if err := printSection(helpdir, c); err == nil {
-					return nil
-				}
+			if subj, err := argSubject.Get(c); err == nil {
+				c.Args = append([]string{subj}, c.Args...)
 			}
-
-		a:
-			for len(c.Args) > 0 {
-				for _, cmd := range subj.Cmd.Commands {
-					if cmd.Name != c.Args[0] {
-						continue
-					}
-					subj.Cmd = cmd
-					c.Args = c.Args[1:]
-					continue a
-				}
-
-				return fmt.Errorf("attempted to resolve unknown command %q on %s", c.Args[0], subj.Cmd.Name)
+			var out strings.Builder
+			if err := render(&out, helpdir, c); err != nil {
+				return err
 			}
-
-			if hl, ok := c.Com.(interface{ Helper() conq.Helper }); ok {
-				fmt.Fprintf(c.Out, "%s\n", hl.Helper().Help(subj))
-				return nil
+			if noPager, _ := optNoPager.Get(c); noPager || cfg.pager == nil {
+				_, err := io.WriteString(c.Out, out.String())
+				return err
 			}
-			return fmt.Errorf("no helper configured con commander")
+			return cfg.pager(c, out.String())
 		},
 	}
 }
 
-func printSection(dir fs.FS, c conq.Ctx) error {
-	var paths []string
-	fs.WalkDir(dir, "help", func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
-			if !strings.HasPrefix(path, "help") {
-				return fs.SkipDir
-			}
+// render the helptext requested by the arguments in c into w.
+func render(w io.Writer, helpdir fs.FS, c conq.Ctx) error {
+	if query, err := optSearch.Get(c); err == nil {
+		return search(w, helpdir, c, query)
+	}
+
+	all, _ := optAll.Get(c)
+	if !all {
+		if err := printSection(w, helpdir, c); err == nil {
 			return nil
 		}
-		if !strings.HasSuffix(path, ".tmpl") {
-			return nil
+	}
+
+	pth := tree.Find(c.Path[0], c.Args...)
+	if pth == nil {
+		return fmt.Errorf("unknown command or help topic %q", strings.Join(c.Args, " "))
+	}
+
+	hl, ok := c.Com.(interface{ Helper() conq.Helper })
+	if !ok {
+		return fmt.Errorf("no helper configured con commander")
+	}
+	if all {
+		fmt.Fprint(w, All(c, pth, hl.Helper()))
+		return nil
+	}
+	fmt.Fprintf(w, "%s\n", Page(c, pth, hl.Helper()))
+	if len(pth) == 1 {
+		if topics := Topics(helpdir, c); len(topics) > 0 {
+			fmt.Fprintf(w, "\nTopics: %s\n", aid.Wrap(strings.Join(topics, ", "), aid.Width(&c), len("Topics: ")))
+		}
+	}
+	return nil
+}
+
+// Page assembles the helptext for the command at the end of pth.  If the
+// command has a CmdHelp.Select, the commands of it's subtree are offered to it
+// and the helptexts of all accepted subjects are concatenated.
+func Page(c conq.Ctx, pth conq.Pth, h conq.Helper) string {
+	sel := pth[len(pth)-1].Help.Select
+	if sel == nil {
+		sel = func(cmd *conq.Cmd, sub conq.HelpSubject, _ conq.Helper, _ string) (bool, bool) {
+			return sub.Cmd == cmd, false
+		}
+	}
+
+	var texts []string
+	for _, subj := range subjects(c, pth, h, sel) {
+		texts = append(texts, h.Help(subj))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// All renders the helptexts of every command in the subtree at the end of pth
+// into a single document.  Every command is introduced by a heading containing
+// it's aid.SubjectIdentifier as an anchor and indented by it's depth below pth.
+func All(c conq.Ctx, pth conq.Pth, h conq.Helper) string {
+	all := func(_ *conq.Cmd, sub conq.HelpSubject, _ conq.Helper, _ string) (bool, bool) {
+		return !sub.Cmd.Help.Hidden, !sub.Cmd.Help.Hidden
+	}
+
+	var b strings.Builder
+	for i, subj := range subjects(c, pth, h, all) {
+		sp := subj.Ctx.Path
+		indent := strings.Repeat("  ", len(sp)-len(pth))
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		names := make([]string, len(sp))
+		for i, x := range sp {
+			names[i] = x.Name
+		}
+		fmt.Fprintf(&b, "%s[%s] %s\n", indent, aid.SubjectIdentifier(sp, nil), strings.Join(names, " "))
+		for _, line := range strings.Split(strings.TrimRight(h.Help(subj), "\n"), "\n") {
+			if line == "" {
+				b.WriteString("\n")
+				continue
+			}
+			fmt.Fprintf(&b, "%s    %s\n", indent, line)
+		}
+	}
+	return b.String()
+}
+
+// subjects walks the subtree at the end of pth and collects the subjects
+// accepted by sel.
+func subjects(c conq.Ctx, pth conq.Pth, h conq.Helper, sel conq.HelpSelector) (subjs []conq.HelpSubject) {
+	target := pth[len(pth)-1]
+	parent := pth[:len(pth)-1]
+	tree.Walk(target, func(sub conq.Pth) error {
+		full := append(parent[:len(parent):len(parent)], sub...)
+		sc := c
+		sc.Path = full
+		subj := conq.HelpSubject{Cmd: full[len(full)-1], Ctx: &sc}
+		accept, recurse := sel(target, subj, h, aid.SubjectIdentifier(full, nil))
+		if accept {
+			subjs = append(subjs, subj)
+		}
+		if !recurse {
+			return tree.SkipCmd
 		}
-		paths = append(paths, path)
 		return nil
 	})
-	if len(paths) == 0 {
-		return fmt.Errorf("no sections found")
-	}
-	tmpl, err := template.ParseFS(dir, paths...)
+	return
+}
+
+func printSection(w io.Writer, dir fs.FS, c conq.Ctx) error {
+	tmpl, err := templates(dir, c)
 	if err != nil {
-		fmt.Fprintf(c.Err, "%v\n", err)
-		return fmt.Errorf("failed parsing help-templates: %w", err)
+		if err != errNoSections {
+			fmt.Fprintf(c.Err, "%v\n", err)
+		}
+		return err
 	}
 
 	path := fmt.Sprintf("%s.tmpl", c.Path[0].Name)
 	if len(c.Args) > 0 {
-		var pth strings.Builder
-		pth.WriteString(c.Args[0])
-		for _, cmd := range c.Args[1:] {
-			fmt.Fprintf(&pth, "/%s", cmd)
-		}
-		pth.WriteString(".tmpl")
-		path = pth.String()
+		path = fmt.Sprintf("%s.tmpl", strings.Join(c.Args, "/"))
+	}
+	t := lookup(tmpl, c.Langs, path)
+	if t == nil && len(c.Args) > 0 {
+		t = lookup(tmpl, c.Langs, fmt.Sprintf("topics/%s", path))
+	}
+	if t == nil {
+		return fmt.Errorf("no section %q", path)
 	}
-	err = tmpl.ExecuteTemplate(c.Out, path, HelpContext(c))
+	// sections about commands are rendered with the command as HelpContext.Cmd
+	if pth := tree.Find(c.Path[0], c.Args...); pth != nil {
+		c.Path = pth
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, HelpContext(c)); err != nil {
+		fmt.Fprintf(c.Err, "%v\n", err)
+		return err
+	}
+	_, err = io.WriteString(w, b.String())
 	return err
 }
 
-// HelpContext is the input for help-templates
-type HelpContext conq.Ctx
+// ErrNoArticle is returned by Article if there is no template for a command.
+var ErrNoArticle = errors.New("no article found")
 
-func (c HelpContext) Root() *conq.Cmd {
-	return c.Path[0]
+// Article renders the help-template for the command at the end of c.Path from
+// helpdir or the CmdHelp.Articles of the tree into w.  Templates are named by
+// the path of the command below the root, the root's template by it's name.
+func Article(w io.Writer, helpdir fs.FS, c conq.Ctx) error {
+	tmpl, err := templates(helpdir, c)
+	if err == errNoSections {
+		return ErrNoArticle
+	}
+	if err != nil {
+		return err
+	}
+	return article(w, tmpl, c)
 }
 
-func (c HelpContext) Cmd() *conq.Cmd {
-	return c.Path[len(c.Path)-1]
+// article renders the template of the command at the end of c.Path from the
+// parsed templates tmpl into w.
+func article(w io.Writer, tmpl *template.Template, c conq.Ctx) error {
+	path := fmt.Sprintf("%s.tmpl", c.Path[0].Name)
+	if len(c.Path) > 1 {
+		names := make([]string, len(c.Path)-1)
+		for i, x := range c.Path[1:] {
+			names[i] = x.Name
+		}
+		path = fmt.Sprintf("%s.tmpl", strings.Join(names, "/"))
+	}
+	t := lookup(tmpl, c.Langs, path)
+	if t == nil {
+		return ErrNoArticle
+	}
+	return t.Execute(w, HelpContext(c))
+}
+
+// lookup the template for path localized to one of langs.  Localized templates
+// reside in directories named by language tags, which are tried for every
+// language in order, from the most specific (eg. "de-DE/foo.tmpl") to the base
+// language ("de/foo.tmpl"), before falling back to the unlocalized template.
+func lookup(tmpl *template.Template, langs []language.Tag, path string) *template.Template {
+	for _, dir := range langDirs(langs) {
+		if t := tmpl.Lookup(fmt.Sprintf("%s/%s", dir, path)); t != nil {
+			return t
+		}
+	}
+	return tmpl.Lookup(path)
+}
+
+// langDirs are the names of directories containing templates for langs, in the
+// order of preference.
+func langDirs(langs []language.Tag) (dirs []string) {
+	seen := make(map[string]bool)
+	for _, lang := range langs {
+		for _, dir := range tagDirs(lang) {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return
+}
+
+// tagDirs are the names of directories containing templates for lang, from
+// most to least specific.
+func tagDirs(lang language.Tag) (dirs []string) {
+	base, conf := lang.Base()
+	if conf == language.No || base.String() == "und" {
+		return nil
+	}
+	if script, conf := lang.Script(); conf == language.Exact {
+		if region, conf := lang.Region(); conf == language.Exact {
+			t, _ := language.Compose(base, script, region)
+			dirs = append(dirs, t.String())
+		}
+		t, _ := language.Compose(base, script)
+		dirs = append(dirs, t.String())
+	}
+	if region, conf := lang.Region(); conf == language.Exact {
+		t, _ := language.Compose(base, region)
+		dirs = append(dirs, t.String())
+	}
+	return append(dirs, base.String())
+}
+
+var errNoSections = errors.New("no sections found")
+
+// templates parses the help-templates from dir and the CmdHelp.Articles of all
+// commands in the tree, named by their path relative to the "help" directory.
+// Later definitions replace earlier ones, including the builtin partials.  The
+// partials are parsed into a template without the ".tmpl" suffix, so they
+// can't be requested as an article or topic.
+func templates(dir fs.FS, c conq.Ctx) (*template.Template, error) {
+	root := c.Path[0]
+	dirs := []fs.FS{}
+	if dir != nil {
+		dirs = append(dirs, dir)
+	}
+	tree.Walk(root, func(pth conq.Pth) error {
+		if a := pth[len(pth)-1].Help.Articles; a != nil {
+			dirs = append(dirs, a)
+		}
+		return nil
+	})
+
+	var tmpl *template.Template
+	fm := funcs(c)
+	fm["topics"] = func() []string {
+		return topicNames(tmpl, c.Langs)
+	}
+	tmpl, err := template.New("builtin partials").Funcs(fm).Parse(partials)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing builtin partials: %w", err)
+	}
+	var found bool
+	for _, dir := range dirs {
+		err := fs.WalkDir(dir, "help", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".tmpl") {
+				return nil
+			}
+			txt, err := fs.ReadFile(dir, path)
+			if err != nil {
+				return err
+			}
+			if _, err := tmpl.New(strings.TrimPrefix(path, "help/")).Parse(string(txt)); err != nil {
+				return fmt.Errorf("failed parsing help-templates: %w", err)
+			}
+			found = true
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, errNoSections
+	}
+	return tmpl, nil
+}
+
+// search prints the results for query with their snippets.
+func search(w io.Writer, helpdir fs.FS, c conq.Ctx, query string) error {
+	results := NewIndex(helpdir, c).Search(query)
+	if len(results) == 0 {
+		return fmt.Errorf("no help found for %q", query)
+	}
+	var subjW int
+	for _, r := range results {
+		if l := len(r.Subject); l > subjW {
+			subjW = l
+		}
+	}
+	for _, r := range results {
+		if r.Snippet == "" {
+			fmt.Fprintf(w, "%s\n", r.Subject)
+			continue
+		}
+		fmt.Fprintf(w, "%-*s  %s\n", subjW, r.Subject, r.Snippet)
+	}
+	return nil
+}
+
+// Topics returns the names of the help topics available in helpdir and the
+// CmdHelp.Articles of the tree in the language of c.
+func Topics(helpdir fs.FS, c conq.Ctx) []string {
+	tmpl, err := templates(helpdir, c)
+	if err != nil {
+		return nil
+	}
+	return topicNames(tmpl, c.Langs)
+}
+
+func topicNames(tmpl *template.Template, langs []language.Tag) (names []string) {
+	seen := make(map[string]bool)
+	prefixes := []string{"topics/"}
+	for _, dir := range langDirs(langs) {
+		prefixes = append(prefixes, fmt.Sprintf("%s/topics/", dir))
+	}
+	for _, t := range tmpl.Templates() {
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(t.Name(), prefix) || !strings.HasSuffix(t.Name(), ".tmpl") {
+				continue
+			}
+			name := strings.TrimSuffix(strings.TrimPrefix(t.Name(), prefix), ".tmpl")
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, strings.ReplaceAll(name, "/", " "))
+			}
+		}
+	}
+	sort.Strings(names)
+	return
 }
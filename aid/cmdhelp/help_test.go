@@ -1,3 +1,319 @@
-package cmdhelp
+package cmdhelp_test
 
-// TODO: test the help command (examples mostly)
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid/cmdhelp"
+	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/getopt"
+	"github.com/posener/complete"
+	"golang.org/x/text/language"
+)
+
+type nameHelper struct{}
+
+func (nameHelper) Help(sub conq.HelpSubject) string {
+	return sub.Cmd.Name
+}
+
+func execute(t *testing.T, root *conq.Cmd, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	ctx := conq.OSContext(args...)
+	ctx.Out = &out
+	if err := commander.New(getopt.New(), nameHelper{}).Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestSelect(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Help: conq.CmdHelp{
+			Select: func(_ *conq.Cmd, sub conq.HelpSubject, _ conq.Helper, id string) (bool, bool) {
+				return sub.Cmd.Name != "help", strings.Count(id, ".") < 1
+			},
+		},
+		Commands: []*conq.Cmd{
+			cmdhelp.New(nil),
+			{Name: "foo", Commands: []*conq.Cmd{{Name: "baz"}}},
+			{Name: "bar"},
+		},
+	}
+
+	if out := execute(t, root, "help"); out != "app\nfoo\nbar\n" {
+		t.Errorf("unexpected help page %q", out)
+	}
+	if out := execute(t, root, "help", "foo"); out != "foo\n" {
+		t.Errorf("unexpected help page %q", out)
+	}
+}
+
+func TestArticles(t *testing.T) {
+	helpdir := fstest.MapFS{
+		"help/app.tmpl":     {Data: []byte("helpdir {{.Root.Name}}")},
+		"help/foo/baz.tmpl": {Data: []byte("helpdir baz")},
+	}
+	articles := fstest.MapFS{
+		"help/foo/baz.tmpl": {Data: []byte("article baz")},
+	}
+	root := &conq.Cmd{
+		Name: "app",
+		Commands: []*conq.Cmd{
+			cmdhelp.New(helpdir),
+			{Name: "foo", Commands: []*conq.Cmd{{Name: "baz"}}, Help: conq.CmdHelp{Articles: articles}},
+		},
+	}
+
+	if out := execute(t, root, "help"); out != "helpdir app" {
+		t.Errorf("unexpected help page %q", out)
+	}
+	if out := execute(t, root, "help", "foo", "baz"); out != "article baz" {
+		t.Errorf("unexpected help page %q", out)
+	}
+	if out := execute(t, root, "help", "foo"); out != "foo\n" {
+		t.Errorf("expected fallback to helper, got %q", out)
+	}
+}
+
+func TestAll(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Commands: []*conq.Cmd{
+			cmdhelp.New(nil),
+			{Name: "foo", Commands: []*conq.Cmd{{Name: "baz"}}},
+		},
+	}
+
+	expect := `[app.foo] app foo
+    foo
+
+  [app.foo.baz] app foo baz
+      baz
+`
+	if out := execute(t, root, "help", "--all", "foo"); out != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, out)
+	}
+}
+
+func TestPager(t *testing.T) {
+	var paged string
+	fake := func(c conq.Ctx, text string) error {
+		paged = text
+		_, err := c.Out.Write([]byte("paged"))
+		return err
+	}
+	root := &conq.Cmd{
+		Name:     "app",
+		Commands: []*conq.Cmd{cmdhelp.New(nil, cmdhelp.WithPager(fake))},
+	}
+
+	if out := execute(t, root, "help"); out != "paged" || paged != "app\n" {
+		t.Errorf("expected help to be paged, got output %q and paged %q", out, paged)
+	}
+
+	paged = ""
+	if out := execute(t, root, "help", "--no-pager"); out != "app\n" || paged != "" {
+		t.Errorf("expected pager to be bypassed, got output %q and paged %q", out, paged)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	helpdir := fstest.MapFS{
+		"help/foo.tmpl": {Data: []byte(`{{template "usage" .}}{{template "commands" .}}{{.Link "foo" "baz"}}
+{{wrap 10 2 "one two three"}}
+{{indent 2 "a\nb"}}
+{{color "red,bold" "plain"}}`)},
+	}
+	root := &conq.Cmd{
+		Name: "app",
+		Commands: []*conq.Cmd{
+			cmdhelp.New(helpdir),
+			{
+				Name: "foo",
+				Opts: conq.Opts{conq.Opt[int]{Name: "depth"}},
+				Commands: []*conq.Cmd{
+					{Name: "baz", Help: conq.CmdHelp{Summary: "the baz"}},
+					{Name: "quux"},
+				},
+			},
+		},
+	}
+
+	expect := `usage: app foo [options]
+Commands:
+  baz   the baz
+  quux
+app foo baz
+one two
+  three
+  a
+  b
+plain`
+	if out := execute(t, root, "help", "foo"); out != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, out)
+	}
+
+	ctx := conq.OSContext("help", "partials")
+	ctx.Out = &bytes.Buffer{}
+	err := commander.New(getopt.New(), nameHelper{}).Execute(root, ctx)
+	if err == nil || err.Error() != `unknown command or help topic "partials"` {
+		t.Errorf("expected the builtin partials not to be a topic, got %v", err)
+	}
+}
+
+func TestLocalizedArticles(t *testing.T) {
+	helpdir := fstest.MapFS{
+		"help/foo.tmpl":       {Data: []byte("default")},
+		"help/de/foo.tmpl":    {Data: []byte("de")},
+		"help/de-DE/foo.tmpl": {Data: []byte("de-DE")},
+	}
+	root := &conq.Cmd{
+		Name:     "app",
+		Commands: []*conq.Cmd{cmdhelp.New(helpdir), {Name: "foo"}},
+	}
+
+	for lang, expect := range map[string]string{"de-DE": "de-DE", "de-CH": "de", "fr": "default", "und": "default"} {
+		var out bytes.Buffer
+		ctx := conq.OSContext("help", "foo")
+		ctx.Out = &out
+		ctx.Langs = []language.Tag{language.MustParse(lang)}
+		if err := commander.New(getopt.New(), nameHelper{}).Execute(root, ctx); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != expect {
+			t.Errorf("%s: expected %q, got %q", lang, expect, out.String())
+		}
+	}
+}
+
+func TestArticleLanguagePreferences(t *testing.T) {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("LANGUAGE", "fr:de")
+	helpdir := fstest.MapFS{
+		"help/foo.tmpl":    {Data: []byte("default")},
+		"help/de/foo.tmpl": {Data: []byte("de")},
+		"help/it/foo.tmpl": {Data: []byte("it")},
+	}
+	root := &conq.Cmd{
+		Name:     "app",
+		Commands: []*conq.Cmd{cmdhelp.New(helpdir), {Name: "foo"}},
+	}
+	if out := execute(t, root, "help", "foo"); out != "de" {
+		t.Errorf("expected the german article, got %q", out)
+	}
+
+	for langs, expect := range map[string]string{"it de": "it", "de it": "de", "fr en": "default"} {
+		var out bytes.Buffer
+		ctx := conq.OSContext("help", "foo")
+		ctx.Out = &out
+		ctx.Langs = nil
+		for _, lang := range strings.Fields(langs) {
+			ctx.Langs = append(ctx.Langs, language.MustParse(lang))
+		}
+		if err := commander.New(getopt.New(), nameHelper{}).Execute(root, ctx); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != expect {
+			t.Errorf("%s: expected %q, got %q", langs, expect, out.String())
+		}
+	}
+}
+
+func TestArticleLanguageFromEnvironment(t *testing.T) {
+	for _, env := range []string{"LANGUAGE", "LC_ALL", "LC_MESSAGES"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("LANG", "de_DE.UTF-8")
+	helpdir := fstest.MapFS{
+		"help/foo.tmpl":    {Data: []byte("default")},
+		"help/de/foo.tmpl": {Data: []byte("de")},
+	}
+	root := &conq.Cmd{
+		Name:     "app",
+		Commands: []*conq.Cmd{cmdhelp.New(helpdir), {Name: "foo"}},
+	}
+	if out := execute(t, root, "help", "foo"); out != "de" {
+		t.Errorf("expected the german article, got %q", out)
+	}
+}
+
+func TestTopics(t *testing.T) {
+	helpdir := fstest.MapFS{
+		"help/topics/environment.tmpl":  {Data: []byte("about the environment")},
+		"help/topics/formats/json.tmpl": {Data: []byte("about json")},
+	}
+	root := &conq.Cmd{
+		Name:     "app",
+		Commands: []*conq.Cmd{cmdhelp.New(helpdir), {Name: "foo"}},
+	}
+
+	if out := execute(t, root, "help"); out != "app\n\nTopics: environment, formats json\n" {
+		t.Errorf("expected topics in help index, got %q", out)
+	}
+	if out := execute(t, root, "help", "formats", "json"); out != "about json" {
+		t.Errorf("unexpected topic %q", out)
+	}
+
+	ctx := conq.OSContext("help", "nope")
+	ctx.Out = &bytes.Buffer{}
+	err := commander.New(getopt.New(), nameHelper{}).Execute(root, ctx)
+	if err == nil || err.Error() != `unknown command or help topic "nope"` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	pred := root.Commands[0].Args[0].Opt().Predict.(conq.CtxPredictor)
+	ctx.Path = conq.Pth{root, root.Commands[0]}
+	got := pred.PredictCtx(ctx, complete.Args{})
+	if strings.Join(got, " ") != "help foo environment formats" {
+		t.Errorf("unexpected predictions %v", got)
+	}
+	got = pred.PredictCtx(ctx, complete.Args{Completed: []string{"formats"}})
+	if strings.Join(got, " ") != "json" {
+		t.Errorf("unexpected predictions %v", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	helpdir := fstest.MapFS{
+		"help/topics/depths.tmpl": {Data: []byte("Everything about the depth of things.")},
+	}
+	root := &conq.Cmd{
+		Name: "app",
+		Commands: []*conq.Cmd{
+			cmdhelp.New(helpdir),
+			{
+				Name: "dig",
+				Help: conq.CmdHelp{Summary: "dig a hole"},
+				Opts: conq.Opts{conq.Opt[int]{Name: "depth,d", Description: "how deep to dig"}},
+			},
+			{Name: "fill", Help: conq.CmdHelp{Summary: "fill a hole"}},
+			{Name: "secret", Help: conq.CmdHelp{Summary: "a hidden hole", Hidden: true}},
+		},
+	}
+
+	ix := cmdhelp.NewIndex(helpdir, conq.Ctx{Path: conq.Pth{root}})
+	var got []string
+	for _, r := range ix.Search("DEPTH") {
+		got = append(got, fmt.Sprintf("%s|%s", r.Subject, r.Snippet))
+	}
+	expect := []string{
+		"topic: depths|Everything about the depth of things.",
+		"app dig|dig a hole",
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+
+	if out := execute(t, root, "help", "--search", "hole"); out != "app dig   dig a hole\napp fill  fill a hole\n" {
+		t.Errorf("unexpected search output %q", out)
+	}
+}
@@ -0,0 +1,40 @@
+package cmdhelp
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+)
+
+// Pager displays text on Ctx.Out.
+type Pager func(c conq.Ctx, text string) error
+
+// SystemPager pipes text through $PAGER, or `less -R` if it isn't set, when
+// Ctx.Out is a terminal and the text is taller than it.  Like git and man do,
+// $PAGER is run by the shell, so it may contain quotes and arguments.
+// Otherwise, or when the pager can't be started, text is written to Ctx.Out
+// directly.
+func SystemPager(c conq.Ctx, text string) error {
+	_, height, ok := conq.TermSize(c.Out)
+	if !ok || height == 0 || strings.Count(text, "\n") < height {
+		_, err := io.WriteString(c.Out, text)
+		return err
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -R"
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = c.Out
+	cmd.Stderr = c.Err
+	if err := cmd.Start(); err != nil {
+		_, err := io.WriteString(c.Out, text)
+		return err
+	}
+	return cmd.Wait()
+}
@@ -0,0 +1,195 @@
+package cmdhelp
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/tree"
+)
+
+// field weights, matches in names rank higher than matches in descriptions,
+// which rank higher than matches in articles.
+const (
+	weightName    = 10
+	weightOption  = 5
+	weightSummary = 3
+	weightArticle = 1
+)
+
+// Index is a full-text search index over the commands, options and articles of
+// a Cmd-tree and the help topics.
+type Index struct {
+	docs []document
+}
+
+type document struct {
+	subject string
+	fields  []field
+}
+
+type field struct {
+	text   string
+	weight int
+}
+
+// Result is a document matching a search query.
+type Result struct {
+	// Subject is the command path or help topic that matched.
+	Subject string
+	Score   int
+	// Snippet is an excerpt around the best match.
+	Snippet string
+}
+
+// NewIndex indexes the tree at c.Path[0] and the articles and topics found in
+// helpdir and the CmdHelp.Articles of the tree.
+func NewIndex(helpdir fs.FS, c conq.Ctx) *Index {
+	// render articles without colors
+	c.Out = &bytes.Buffer{}
+	c.Com = nil
+
+	// the templates are parsed once for all articles and topics
+	tmpl, err := templates(helpdir, c)
+	ix := &Index{}
+	tree.Walk(c.Path[0], func(pth conq.Pth) error {
+		cmd := pth[len(pth)-1]
+		if cmd.Help.Hidden {
+			return tree.SkipCmd
+		}
+		names := make([]string, len(pth))
+		for i, x := range pth {
+			names[i] = x.Name
+		}
+		doc := document{subject: strings.Join(names, " ")}
+		doc.fields = append(doc.fields,
+			field{cmd.Name, weightName},
+			field{cmd.Help.Summary, weightSummary},
+		)
+		for _, opts := range []conq.Opts{cmd.Opts, cmd.Args, cmd.Env} {
+			for _, opt := range opts {
+				o := opt.Opt()
+				doc.fields = append(doc.fields,
+					field{strings.ReplaceAll(o.Name, ",", " "), weightOption},
+					field{o.Description, weightSummary},
+				)
+			}
+		}
+
+		ac := c
+		ac.Path = pth
+		var text bytes.Buffer
+		if err == nil && article(&text, tmpl, ac) == nil {
+			doc.fields = append(doc.fields, field{text.String(), weightArticle})
+		}
+		ix.docs = append(ix.docs, doc)
+		return nil
+	})
+
+	if err != nil {
+		return ix
+	}
+	for _, topic := range topicNames(tmpl, c.Langs) {
+		t := lookup(tmpl, c.Langs, fmt.Sprintf("topics/%s.tmpl", strings.ReplaceAll(topic, " ", "/")))
+		var text bytes.Buffer
+		if t == nil || t.Execute(&text, HelpContext(c)) != nil {
+			continue
+		}
+		ix.docs = append(ix.docs, document{
+			subject: fmt.Sprintf("topic: %s", topic),
+			fields:  []field{{topic, weightName}, {text.String(), weightArticle}},
+		})
+	}
+	return ix
+}
+
+// Search returns the documents containing every word of query, case
+// insensitive, ordered by descending score.
+func (ix *Index) Search(query string) (results []Result) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	for _, doc := range ix.docs {
+		var score int
+		matched := true
+		for _, term := range terms {
+			var n int
+			for _, f := range doc.fields {
+				n += strings.Count(strings.ToLower(f.text), term) * f.weight
+			}
+			if n == 0 {
+				matched = false
+				break
+			}
+			score += n
+		}
+		if !matched {
+			continue
+		}
+		results = append(results, Result{
+			Subject: doc.subject,
+			Score:   score,
+			Snippet: doc.snippet(terms[0]),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return
+}
+
+// snippet is an excerpt around term from the highest weighted field containing
+// it.  Matches in command and option names are skipped in favor of the
+// summary, since they make for poor excerpts.
+func (doc document) snippet(term string) string {
+	const context = 30
+	best := -1
+	for i, f := range doc.fields {
+		if f.weight >= weightOption || !strings.Contains(strings.ToLower(f.text), term) {
+			continue
+		}
+		if best == -1 || f.weight > doc.fields[best].weight {
+			best = i
+		}
+	}
+	if best == -1 {
+		for _, f := range doc.fields {
+			if f.weight == weightSummary && f.text != "" {
+				return f.text
+			}
+		}
+		return ""
+	}
+
+	text := []rune(strings.Join(strings.Fields(doc.fields[best].text), " "))
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	idx := strings.Index(string(lower), term)
+	if idx < 0 {
+		idx = 0
+	}
+	idx = len([]rune(string(lower)[:idx]))
+
+	start, end := idx-context, idx+len([]rune(term))+context
+	var prefix, suffix string
+	if start <= 0 {
+		start = 0
+	} else {
+		prefix = "..."
+	}
+	if end >= len(text) {
+		end = len(text)
+	} else {
+		suffix = "..."
+	}
+	return prefix + string(text[start:end]) + suffix
+}
@@ -0,0 +1,150 @@
+// Package docs generates Markdown reference documentation from a Cmd-tree,
+// with one page per command.
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid"
+	"github.com/patroclos/go-conq/aid/cmdhelp"
+	"github.com/patroclos/go-conq/tree"
+)
+
+// Generator renders the documentation pages.
+type Generator struct {
+	// Articles is searched for help-templates like the help-directory of
+	// cmdhelp.New.  Templates found there or in the CmdHelp.Articles of the
+	// tree are included in the page of their command.
+	Articles fs.FS
+	// Ctx is the context templates are executed in.  It's Path is set to the
+	// documented command.
+	Ctx conq.Ctx
+}
+
+// FileName returns the name of the page for the command at the end of pth.
+func FileName(pth conq.Pth) string {
+	return fmt.Sprintf("%s.md", aid.SubjectIdentifier(pth, nil))
+}
+
+// Generate writes a page for every command in the tree to dir.
+func (g Generator) Generate(dir string, root *conq.Cmd) error {
+	return tree.Walk(root, func(pth conq.Pth) error {
+		if pth[len(pth)-1].Help.Hidden {
+			return tree.SkipCmd
+		}
+		return aid.WriteFile(filepath.Join(dir, FileName(pth)), "documentation page", func(w io.Writer) error {
+			return g.Page(w, pth)
+		})
+	})
+}
+
+// Page writes the page for the command at the end of pth.
+func (g Generator) Page(w io.Writer, pth conq.Pth) error {
+	cmd := pth[len(pth)-1]
+	names := make([]string, len(pth))
+	for i, x := range pth {
+		names[i] = x.Name
+	}
+	title := strings.Join(names, " ")
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %q\n", title)
+	fmt.Fprintf(&b, "identifier: %q\n", aid.SubjectIdentifier(pth, nil))
+	if len(pth) > 1 {
+		fmt.Fprintf(&b, "parent: %q\n", aid.SubjectIdentifier(pth[:len(pth)-1], nil))
+	}
+	if cmd.Help.Summary != "" {
+		fmt.Fprintf(&b, "summary: %q\n", cmd.Help.Summary)
+	}
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if cmd.Help.Summary != "" {
+		fmt.Fprintf(&b, "%s\n\n", cmd.Help.Summary)
+	}
+	fmt.Fprintf(&b, "```\n%s\n```\n\n", aid.Usage(pth))
+
+	if len(pth) > 1 {
+		parent := pth[:len(pth)-1]
+		fmt.Fprintf(&b, "Parent: [%s](%s)\n\n", strings.Join(names[:len(names)-1], " "), FileName(parent))
+	}
+
+	table(&b, "Options", cmd.Opts, func(name string) string {
+		if len(name) == 1 {
+			return fmt.Sprintf("`-%s`", name)
+		}
+		return fmt.Sprintf("`--%s`", name)
+	})
+	table(&b, "Arguments", cmd.Args, func(name string) string {
+		return fmt.Sprintf("`%s`", name)
+	})
+	table(&b, "Environment", cmd.Env, func(name string) string {
+		return fmt.Sprintf("`%s`", name)
+	})
+
+	var subs []*conq.Cmd
+	for _, sub := range cmd.Commands {
+		if !sub.Help.Hidden {
+			subs = append(subs, sub)
+		}
+	}
+	if len(subs) > 0 {
+		b.WriteString("## Commands\n\n| Command | Summary |\n| --- | --- |\n")
+		for _, sub := range subs {
+			sp := append(pth[:len(pth):len(pth)], sub)
+			fmt.Fprintf(&b, "| [%s](%s) | %s |\n", cell(sub.Name), FileName(sp), cell(sub.Help.Summary))
+		}
+		b.WriteString("\n")
+	}
+
+	ctx := g.Ctx
+	ctx.Path = pth
+	var article bytes.Buffer
+	switch err := cmdhelp.Article(&article, g.Articles, ctx); err {
+	case nil:
+		fmt.Fprintf(&b, "%s\n", strings.TrimSpace(article.String()))
+	case cmdhelp.ErrNoArticle:
+	default:
+		return fmt.Errorf("failed rendering article for %q: %w", title, err)
+	}
+
+	_, err := io.WriteString(w, strings.TrimRight(b.String(), "\n")+"\n")
+	return err
+}
+
+func table(b *strings.Builder, title string, opts conq.Opts, name func(string) string) {
+	if len(opts) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "## %s\n\n| Name | Type | Required | Description |\n| --- | --- | --- | --- |\n", title)
+	for _, opt := range opts {
+		o := opt.Opt()
+		aliases := strings.Split(o.Name, ",")
+		for i, a := range aliases {
+			aliases[i] = name(cell(a))
+		}
+		var typ string
+		if o.Type != nil {
+			typ = fmt.Sprintf("`%s`", o.Type)
+		}
+		req := "no"
+		if o.Require {
+			req = "yes"
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", strings.Join(aliases, ", "), typ, req, cell(o.Description))
+	}
+	b.WriteString("\n")
+}
+
+// cell escapes text for use in a table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,91 @@
+package docs_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid/docs"
+)
+
+func TestPage(t *testing.T) {
+	foo := &conq.Cmd{
+		Name:     "foo",
+		Help:     conq.CmdHelp{Summary: "do foo-things"},
+		Opts:     conq.Opts{conq.ReqOpt[int]{Name: "depth,d", Description: "how | deep"}},
+		Commands: []*conq.Cmd{{Name: "baz", Help: conq.CmdHelp{Summary: "bazzing"}}},
+	}
+	root := &conq.Cmd{Name: "app", Commands: []*conq.Cmd{foo}}
+
+	g := docs.Generator{
+		Articles: fstest.MapFS{
+			"help/foo.tmpl": {Data: []byte("Article about {{.Cmd.Name}}.")},
+		},
+	}
+
+	var b strings.Builder
+	if err := g.Page(&b, conq.Pth{root, foo}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "---\n" +
+		"title: \"app foo\"\n" +
+		"identifier: \"app.foo\"\n" +
+		"parent: \"app\"\n" +
+		"summary: \"do foo-things\"\n" +
+		"---\n\n" +
+		"# app foo\n\n" +
+		"do foo-things\n\n" +
+		"```\napp foo [options]\n```\n\n" +
+		"Parent: [app](app.md)\n\n" +
+		"## Options\n\n" +
+		"| Name | Type | Required | Description |\n| --- | --- | --- | --- |\n" +
+		"| `--depth`, `-d` | `int` | yes | how \\| deep |\n\n" +
+		"## Commands\n\n" +
+		"| Command | Summary |\n| --- | --- |\n" +
+		"| [baz](app.foo.baz.md) | bazzing |\n\n" +
+		"Article about foo.\n"
+	if b.String() != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, b.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	foo := &conq.Cmd{Name: "foo", Help: conq.CmdHelp{Summary: "do foo-things"}}
+	root := &conq.Cmd{
+		Name:     "app",
+		Commands: []*conq.Cmd{foo, {Name: "secret", Help: conq.CmdHelp{Hidden: true}}},
+	}
+	var g docs.Generator
+	dir := t.TempDir()
+	if err := g.Generate(dir, root); err != nil {
+		t.Fatal(err)
+	}
+
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range ents {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, " ") != "app.foo.md app.md" {
+		t.Errorf("unexpected pages %v", names)
+	}
+
+	var expect strings.Builder
+	if err := g.Page(&expect, conq.Pth{root, foo}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "app.foo.md")); err != nil || string(got) != expect.String() {
+		t.Errorf("expected app.foo.md to be\n%s\ngot\n%s (%v)", expect.String(), got, err)
+	}
+
+	if err := g.Generate(filepath.Join(dir, "missing"), root); err == nil {
+		t.Error("expected an error generating into a missing directory")
+	}
+}
@@ -0,0 +1,242 @@
+// Package man generates troff man pages (section 1) from a Cmd-tree.
+package man
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid"
+	"github.com/patroclos/go-conq/tree"
+)
+
+var (
+	optCombined = conq.Opt[bool]{Name: "combined", Description: "write a single page covering the whole tree"}
+	argDir      = conq.Opt[string]{Name: "dir", Description: "directory to write the pages to"}
+)
+
+// CmdGenMan writes man pages for the tree it is mounted in.  Without a
+// directory argument the combined page is written to Ctx.Out.
+var CmdGenMan *conq.Cmd = &conq.Cmd{
+	Name: "gen-man",
+	Opts: conq.Opts{optCombined},
+	Args: conq.Opts{argDir},
+	Help: conq.CmdHelp{Summary: "generate man pages"},
+	Run: func(c conq.Ctx) error {
+		root := c.Path[0]
+		dir, err := argDir.Get(c)
+		if err != nil {
+			return Combined(c.Out, root)
+		}
+		if combined, _ := optCombined.Get(c); combined {
+			return aid.WriteFile(filepath.Join(dir, fmt.Sprintf("%s.1", root.Name)), "man page", func(w io.Writer) error {
+				return Combined(w, root)
+			})
+		}
+		return Generate(dir, root)
+	},
+}
+
+// Name returns the name of the man page for the command at the end of pth,
+// which is the command names joined by dashes.
+func Name(pth conq.Pth) string {
+	names := make([]string, len(pth))
+	for i, x := range pth {
+		names[i] = x.Name
+	}
+	return strings.Join(names, "-")
+}
+
+// Generate writes one page per command in the tree to dir, named by Name and
+// suffixed with the section.
+func Generate(dir string, root *conq.Cmd) error {
+	return tree.Walk(root, func(pth conq.Pth) error {
+		if pth[len(pth)-1].Help.Hidden {
+			return tree.SkipCmd
+		}
+		return aid.WriteFile(filepath.Join(dir, fmt.Sprintf("%s.1", Name(pth))), "man page", func(w io.Writer) error {
+			return Page(w, pth)
+		})
+	})
+}
+
+// Page writes the man page for the command at the end of pth.
+func Page(w io.Writer, pth conq.Pth) error {
+	cmd := pth[len(pth)-1]
+	var b strings.Builder
+	header(&b, pth)
+
+	b.WriteString(".SH SYNOPSIS\n")
+	synopsis(&b, pth)
+
+	if cmd.Help.Summary != "" {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n%s\n", escape(cmd.Help.Summary))
+	}
+
+	params(&b, ".SH", cmd)
+
+	var subs []*conq.Cmd
+	for _, sub := range cmd.Commands {
+		if !sub.Help.Hidden {
+			subs = append(subs, sub)
+		}
+	}
+	if len(subs) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, sub := range subs {
+			fmt.Fprintf(&b, ".TP\n.B %s\n", escape(sub.Name))
+			if sub.Help.Summary != "" {
+				fmt.Fprintf(&b, "%s\n", escape(sub.Help.Summary))
+			}
+		}
+
+		b.WriteString(".SH SEE ALSO\n")
+		for i, sub := range subs {
+			if i > 0 {
+				b.WriteString(",\n")
+			}
+			fmt.Fprintf(&b, "\\fB%s\\fR(1)", escape(Name(append(pth[:len(pth):len(pth)], sub))))
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Combined writes a single man page covering every command in the tree.
+func Combined(w io.Writer, root *conq.Cmd) error {
+	var b strings.Builder
+	pth := conq.Pth{root}
+	header(&b, pth)
+
+	b.WriteString(".SH SYNOPSIS\n")
+	tree.Walk(root, func(pth conq.Pth) error {
+		if pth[len(pth)-1].Help.Hidden {
+			return tree.SkipCmd
+		}
+		synopsis(&b, pth)
+		b.WriteString(".br\n")
+		return nil
+	})
+
+	if root.Help.Summary != "" {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n%s\n", escape(root.Help.Summary))
+	}
+
+	params(&b, ".SH", root)
+
+	if len(root.Commands) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, sub := range root.Commands {
+			tree.Walk(sub, func(sp conq.Pth) error {
+				full := append(conq.Pth{root}, sp...)
+				cmd := full[len(full)-1]
+				if cmd.Help.Hidden {
+					return tree.SkipCmd
+				}
+				names := make([]string, len(full)-1)
+				for i, x := range full[1:] {
+					names[i] = x.Name
+				}
+				fmt.Fprintf(&b, ".SS \"%s\"\n", escape(strings.Join(names, " ")))
+				if cmd.Help.Summary != "" {
+					fmt.Fprintf(&b, "%s\n", escape(cmd.Help.Summary))
+				}
+				params(&b, ".B", cmd)
+				return nil
+			})
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func header(b *strings.Builder, pth conq.Pth) {
+	name := Name(pth)
+	source := pth[0].Name
+	if version := pth.Version(); version != "" {
+		source = fmt.Sprintf("%s %s", source, version)
+	}
+	fmt.Fprintf(b, ".TH \"%s\" \"1\" \"\" \"%s\" \"User Commands\"\n", escape(strings.ToUpper(name)), escape(source))
+	fmt.Fprintf(b, ".SH NAME\n%s", escape(name))
+	if s := pth[len(pth)-1].Help.Summary; s != "" {
+		fmt.Fprintf(b, " \\- %s", escape(s))
+	}
+	b.WriteString("\n")
+}
+
+// synopsis renders the usage line like aid.Usage with the command names in bold.
+func synopsis(b *strings.Builder, pth conq.Pth) {
+	names := make([]string, len(pth))
+	for i, x := range pth {
+		names[i] = x.Name
+	}
+	cmdline := strings.Join(names, " ")
+	usage := strings.TrimPrefix(aid.Usage(pth), cmdline)
+	fmt.Fprintf(b, "\\fB%s\\fR%s\n", escape(cmdline), escape(usage))
+}
+
+// params renders the options, positional arguments and environment variables
+// of cmd into sections introduced by the macro sect.
+func params(b *strings.Builder, sect string, cmd *conq.Cmd) {
+	sections := []struct {
+		title string
+		opts  conq.Opts
+		name  func(string) string
+	}{
+		{"OPTIONS", cmd.Opts, flag},
+		{"ARGUMENTS", cmd.Args, func(n string) string { return fmt.Sprintf("\\fI%s\\fR", escape(n)) }},
+		{"ENVIRONMENT", cmd.Env, func(n string) string { return fmt.Sprintf("\\fB%s\\fR", escape(n)) }},
+	}
+	for _, s := range sections {
+		if len(s.opts) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "%s %s\n", sect, s.title)
+		for _, opt := range s.opts {
+			o := opt.Opt()
+			b.WriteString(".TP\n")
+			for i, name := range strings.Split(o.Name, ",") {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(s.name(name))
+			}
+			if o.Type != nil {
+				fmt.Fprintf(b, " \\fI%s\\fR", escape(o.Type.Name()))
+			}
+			if o.Require {
+				b.WriteString(" (required)")
+			}
+			b.WriteString("\n")
+			if o.Description != "" {
+				fmt.Fprintf(b, "%s\n", escape(o.Description))
+			}
+		}
+	}
+}
+
+func flag(name string) string {
+	if len(name) == 1 {
+		return fmt.Sprintf("\\fB\\-%s\\fR", escape(name))
+	}
+	return fmt.Sprintf("\\fB\\-\\-%s\\fR", escape(name))
+}
+
+// escape text for use in troff, so it isn't interpreted as requests or escapes.
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	s = strings.ReplaceAll(s, "\"", "\\(dq")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = "\\&" + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
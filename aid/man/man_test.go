@@ -0,0 +1,192 @@
+package man_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid/man"
+	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/getopt"
+)
+
+func TestPage(t *testing.T) {
+	foo := &conq.Cmd{
+		Name: "foo",
+		Help: conq.CmdHelp{Summary: "do foo-things"},
+		Opts: conq.Opts{conq.ReqOpt[int]{Name: "depth,d", Description: "how deep"}},
+		Args: conq.Opts{conq.Opt[string]{Name: "query"}},
+		Env:  conq.Opts{conq.Opt[string]{Name: "FOO_HOME"}},
+		Commands: []*conq.Cmd{
+			{Name: "baz"},
+		},
+	}
+	root := &conq.Cmd{Name: "app", Version: "1.0", Commands: []*conq.Cmd{foo}}
+
+	var b strings.Builder
+	if err := man.Page(&b, conq.Pth{root, foo}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `.TH "APP\-FOO" "1" "" "app 1.0" "User Commands"
+.SH NAME
+app\-foo \- do foo\-things
+.SH SYNOPSIS
+\fBapp foo\fR [options] [query]
+.SH DESCRIPTION
+do foo\-things
+.SH OPTIONS
+.TP
+\fB\-\-depth\fR, \fB\-d\fR \fIint\fR (required)
+how deep
+.SH ARGUMENTS
+.TP
+\fIquery\fR \fIstring\fR
+.SH ENVIRONMENT
+.TP
+\fBFOO_HOME\fR \fIstring\fR
+.SH COMMANDS
+.TP
+.B baz
+.SH SEE ALSO
+\fBapp\-foo\-baz\fR(1)
+`
+	if b.String() != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, b.String())
+	}
+}
+
+// tree is an app with nested and hidden commands.
+func tree() *conq.Cmd {
+	return &conq.Cmd{
+		Name: "app",
+		Help: conq.CmdHelp{Summary: "the app"},
+		Opts: conq.Opts{conq.Opt[bool]{Name: "verbose,v"}},
+		Commands: []*conq.Cmd{
+			{Name: "foo", Help: conq.CmdHelp{Summary: "do foo"}, Commands: []*conq.Cmd{
+				{Name: "baz", Args: conq.Opts{conq.Opt[string]{Name: "query"}}},
+			}},
+			{Name: "secret", Help: conq.CmdHelp{Hidden: true}},
+			man.CmdGenMan,
+		},
+	}
+}
+
+const combined = `.TH "APP" "1" "" "app" "User Commands"
+.SH NAME
+app \- the app
+.SH SYNOPSIS
+\fBapp\fR [options]
+.br
+\fBapp foo\fR
+.br
+\fBapp foo baz\fR [query]
+.br
+\fBapp gen\-man\fR [options] [dir]
+.br
+.SH DESCRIPTION
+the app
+.SH OPTIONS
+.TP
+\fB\-\-verbose\fR, \fB\-v\fR \fIbool\fR
+.SH COMMANDS
+.SS "foo"
+do foo
+.SS "foo baz"
+.B ARGUMENTS
+.TP
+\fIquery\fR \fIstring\fR
+.SS "gen\-man"
+generate man pages
+.B OPTIONS
+.TP
+\fB\-\-combined\fR \fIbool\fR
+write a single page covering the whole tree
+.B ARGUMENTS
+.TP
+\fIdir\fR \fIstring\fR
+directory to write the pages to
+`
+
+func TestCombined(t *testing.T) {
+	var b strings.Builder
+	if err := man.Combined(&b, tree()); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != combined {
+		t.Errorf("expected\n%s\ngot\n%s", combined, b.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	root := tree()
+	dir := t.TempDir()
+	if err := man.Generate(dir, root); err != nil {
+		t.Fatal(err)
+	}
+
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range ents {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, " ") != "app-foo-baz.1 app-foo.1 app-gen-man.1 app.1" {
+		t.Errorf("unexpected pages %v", names)
+	}
+
+	var expect strings.Builder
+	if err := man.Page(&expect, conq.Pth{root, root.Commands[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "app-foo.1")); err != nil || string(got) != expect.String() {
+		t.Errorf("expected app-foo.1 to be\n%s\ngot\n%s (%v)", expect.String(), got, err)
+	}
+
+	if err := man.Generate(filepath.Join(dir, "missing"), root); err == nil {
+		t.Error("expected an error generating into a missing directory")
+	}
+}
+
+func TestCmdGenMan(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		args  []string
+		out   string
+		pages string
+	}{
+		{[]string{"gen-man"}, combined, ""},
+		{[]string{"gen-man", dir}, "", "app-foo-baz.1 app-foo.1 app-gen-man.1 app.1"},
+		{[]string{"gen-man", "--combined", dir}, "", "app-foo-baz.1 app-foo.1 app-gen-man.1 app.1"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		ctx := conq.OSContext(tt.args...)
+		ctx.Out = &out
+		if err := commander.New(getopt.New(), nil).Execute(tree(), ctx); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if out.String() != tt.out {
+			t.Errorf("%v: expected output\n%s\ngot\n%s", tt.args, tt.out, out.String())
+		}
+
+		ents, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range ents {
+			names = append(names, e.Name())
+		}
+		if strings.Join(names, " ") != tt.pages {
+			t.Errorf("%v: unexpected pages %v", tt.args, names)
+		}
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "app.1")); err != nil || string(b) != combined {
+		t.Errorf("expected the combined page to replace app.1, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Package schema exports a Cmd-tree as a versioned, machine-readable JSON
+// document, so external tools can introspect a CLI without parsing helptexts.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/completion"
+)
+
+// Version of the schema format, incremented on incompatible changes.
+const Version = 1
+
+// Schema is the root of the exported document.
+type Schema struct {
+	Version int     `json:"version"`
+	Root    Command `json:"root"`
+}
+
+// Command describes a Cmd.
+type Command struct {
+	Name     string    `json:"name"`
+	Path     []string  `json:"path"`
+	Summary  string    `json:"summary,omitempty"`
+	Version  string    `json:"version,omitempty"`
+	Hidden   bool      `json:"hidden,omitempty"`
+	Runnable bool      `json:"runnable"`
+	Options  []Param   `json:"options,omitempty"`
+	Args     []Param   `json:"args,omitempty"`
+	Env      []Param   `json:"env,omitempty"`
+	Commands []Command `json:"commands,omitempty"`
+}
+
+// Param describes an option, positional argument or environment variable.
+type Param struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Kind        string   `json:"kind,omitempty"`
+	Required    bool     `json:"required"`
+	Default     string   `json:"default,omitempty"`
+	Description string   `json:"description,omitempty"`
+	// Choices are the candidates of static completion predictors.
+	Choices []string `json:"choices,omitempty"`
+}
+
+// CmdSchema prints the schema of the tree it is mounted in to Ctx.Out.
+var CmdSchema *conq.Cmd = &conq.Cmd{
+	Name: "schema",
+	Help: conq.CmdHelp{Summary: "print the command schema as JSON", Hidden: true},
+	Run: func(c conq.Ctx) error {
+		enc := json.NewEncoder(c.Out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(Export(c.Path[0])); err != nil {
+			return fmt.Errorf("failed encoding schema: %w", err)
+		}
+		return nil
+	},
+}
+
+// Export describes the tree rooted at root.
+func Export(root *conq.Cmd) Schema {
+	return Schema{
+		Version: Version,
+		Root:    command(conq.Pth{root}),
+	}
+}
+
+func command(pth conq.Pth) Command {
+	cmd := pth[len(pth)-1]
+	names := make([]string, len(pth))
+	for i, x := range pth {
+		names[i] = x.Name
+	}
+	c := Command{
+		Name:     cmd.Name,
+		Path:     names,
+		Summary:  cmd.Help.Summary,
+		Version:  cmd.Version,
+		Hidden:   cmd.Help.Hidden,
+		Runnable: cmd.Run != nil,
+		Options:  params(cmd.Opts),
+		Args:     params(cmd.Args),
+		Env:      params(cmd.Env),
+	}
+	for _, sub := range cmd.Commands {
+		c.Commands = append(c.Commands, command(append(pth[:len(pth):len(pth)], sub)))
+	}
+	return c
+}
+
+func params(opts conq.Opts) (ps []Param) {
+	for _, opt := range opts {
+		o := opt.Opt()
+		names := strings.Split(o.Name, ",")
+		p := Param{
+			Name:        names[0],
+			Aliases:     names[1:],
+			Required:    o.Require,
+			Default:     o.Default,
+			Description: o.Description,
+		}
+		if len(p.Aliases) == 0 {
+			p.Aliases = nil
+		}
+		if o.Type != nil {
+			p.Type = o.Type.String()
+			p.Kind = o.Type.Kind().String()
+		}
+		p.Choices, _ = completion.Choices(o.Predict)
+		ps = append(ps, p)
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package schema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid/schema"
+	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/getopt"
+	"github.com/posener/complete"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	root := &conq.Cmd{
+		Name:    "app",
+		Version: "1.2.3",
+		Opts: conq.Opts{
+			conq.ReqOpt[int]{Name: "depth,d", Description: "how deep"},
+			conq.Opt[string]{Name: "mode", Default: "fast", Predict: complete.PredictSet("fast", "slow")},
+		},
+		Args:     conq.Opts{conq.Opt[string]{Name: "query"}},
+		Commands: []*conq.Cmd{schema.CmdSchema},
+	}
+
+	var out bytes.Buffer
+	ctx := conq.OSContext("schema")
+	ctx.Out = &out
+	if err := commander.New(getopt.New(), nil).Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var got schema.Schema
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := schema.Schema{
+		Version: schema.Version,
+		Root: schema.Command{
+			Name:    "app",
+			Path:    []string{"app"},
+			Version: "1.2.3",
+			Options: []schema.Param{
+				{Name: "depth", Aliases: []string{"d"}, Type: "int", Kind: "int", Required: true, Description: "how deep"},
+				{Name: "mode", Type: "string", Kind: "string", Default: "fast", Choices: []string{"fast", "slow"}},
+			},
+			Args: []schema.Param{{Name: "query", Type: "string", Kind: "string"}},
+			Commands: []schema.Command{{
+				Name:     "schema",
+				Path:     []string{"app", "schema"},
+				Summary:  "print the command schema as JSON",
+				Hidden:   true,
+				Runnable: true,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected\n%+v\ngot\n%+v", expect, got)
+	}
+}
@@ -0,0 +1,104 @@
+// Package version reports the version of a program from Cmd.Version and the
+// build information embedded by the go tool.
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+)
+
+var (
+	optBuild = conq.Opt[bool]{Name: "build,b", Description: "include module, VCS and Go version information"}
+	optJSON  = conq.Opt[bool]{Name: "json", Description: "print the version as JSON"}
+)
+
+// CmdVersion prints the version of the tree it is mounted in.
+var CmdVersion *conq.Cmd = &conq.Cmd{
+	Name: "version",
+	Opts: conq.Opts{optBuild, optJSON},
+	Help: conq.CmdHelp{Summary: "print version information"},
+	Run: func(c conq.Ctx) error {
+		build, _ := optBuild.Get(c)
+		// the path ends in this command, which is why it's parent is asked
+		info := Get(c.Path[:len(c.Path)-1], build)
+		if asJSON, _ := optJSON.Get(c); asJSON {
+			enc := json.NewEncoder(c.Out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(info); err != nil {
+				return fmt.Errorf("failed encoding version: %w", err)
+			}
+			return nil
+		}
+		_, err := fmt.Fprint(c.Out, info)
+		return err
+	},
+}
+
+// Info describes the version of a program.
+type Info struct {
+	Name string `json:"name"`
+	// Version is the nearest Cmd.Version up the path.
+	Version string `json:"version,omitempty"`
+
+	// The rest is only known when the build information is requested and was
+	// embedded into the binary.
+	Module        string `json:"module,omitempty"`
+	ModuleVersion string `json:"moduleVersion,omitempty"`
+	Revision      string `json:"revision,omitempty"`
+	Modified      bool   `json:"modified,omitempty"`
+	GoVersion     string `json:"goVersion,omitempty"`
+}
+
+// Get returns the version of the command at the end of pth, including the
+// build information of the running binary if build is set.
+func Get(pth conq.Pth, build bool) Info {
+	info := Info{Name: pth[0].Name, Version: pth.Version()}
+	if !build {
+		return info
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = bi.Main.Path
+	info.ModuleVersion = bi.Main.Version
+	info.GoVersion = bi.GoVersion
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// String formats the version like "name version", followed by a line for each
+// piece of build information that's known.
+func (i Info) String() string {
+	var b strings.Builder
+	b.WriteString(i.Name)
+	if i.Version != "" {
+		fmt.Fprintf(&b, " %s", i.Version)
+	}
+	b.WriteString("\n")
+	if i.Module != "" {
+		fmt.Fprintf(&b, "module:   %s %s\n", i.Module, i.ModuleVersion)
+	}
+	if i.Revision != "" {
+		dirty := ""
+		if i.Modified {
+			dirty = " (modified)"
+		}
+		fmt.Fprintf(&b, "revision: %s%s\n", i.Revision, dirty)
+	}
+	if i.GoVersion != "" {
+		fmt.Fprintf(&b, "go:       %s\n", i.GoVersion)
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package version_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid/version"
+	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/getopt"
+)
+
+func TestVersion(t *testing.T) {
+	sub := &conq.Cmd{Name: "sub", Version: "2.0.0", Commands: []*conq.Cmd{version.CmdVersion}}
+	root := &conq.Cmd{
+		Name:     "app",
+		Version:  "1.2.3",
+		Commands: []*conq.Cmd{version.CmdVersion, sub},
+	}
+
+	tests := []struct {
+		args   []string
+		expect string
+	}{
+		{[]string{"version"}, "app 1.2.3\n"},
+		{[]string{"sub", "version"}, "app 2.0.0\n"},
+		{[]string{"--version"}, "app 1.2.3\n"},
+		{[]string{"sub", "--version"}, "app 2.0.0\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		ctx := conq.OSContext(tt.args...)
+		ctx.Out = &out
+		if err := commander.New(getopt.New(), nil).Execute(root, ctx); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tt.expect {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.expect, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	ctx := conq.OSContext("version", "--json", "--build")
+	ctx.Out = &out
+	if err := commander.New(getopt.New(), nil).Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+	var info version.Info
+	if err := json.Unmarshal(out.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "app" || info.Version != "1.2.3" || info.GoVersion == "" {
+		t.Errorf("unexpected version info %+v", info)
+	}
+}
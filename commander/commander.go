@@ -3,6 +3,7 @@ package commander
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 
 	"github.com/patroclos/go-conq"
@@ -13,6 +14,13 @@ type Commander struct {
 	O conq.Optioner
 	H conq.Helper
 	P *message.Printer
+	// M wraps the Run of every invoked command.  The first middleware is the
+	// outermost one and runs before any of the Cmd.Hooks.Wrap wrappers.
+	M []conq.Middleware
+	// Color is the policy for colored output, which can be overridden by
+	// passing --color=auto|always|never among the options of the invoked
+	// command or its parents, unless the invoked command declares a "color" option itself.
+	Color conq.ColorMode
 }
 
 func New(o conq.Optioner, h conq.Helper) Commander {
@@ -30,34 +38,136 @@ func (c Commander) Helper() conq.Helper {
 	return c.H
 }
 
-func (c Commander) Execute(root *conq.Cmd, ctx conq.Ctx) error {
+func (c Commander) ColorMode() conq.ColorMode {
+	return c.Color
+}
+
+// Execute resolves the invoked command, extracts and validates it's options,
+// environment and positional arguments and runs it.  The Cmd.Hooks along the
+// resolved path and the commanders middleware are applied in the order
+// documented on conq.Hooks.
+//
+// If -h or --help is given to the invoked command, it's help is printed using
+// the Helper instead.  Likewise --version prints the nearest Cmd.Version up the
+// path, if there is one.  These flags are handled before any of the Hooks and
+// middleware, which don't run for such invocations.
+func (c Commander) Execute(root *conq.Cmd, ctx conq.Ctx) (err error) {
 	ctx.Values = nil
 	ctx.Strings = nil
+	if args, mode, ok, err := colorFlag(root, ctx.Args); ok {
+		stripped := ctx
+		stripped.Args = args
+		pth := c.ResolveCmd(root, stripped).Path
+		if !declares(pth[len(pth)-1], "color") {
+			if err != nil {
+				return err
+			}
+			ctx.Args = args
+			c.Color = mode
+		}
+	}
 	ctx.Com = c
 	ctx = c.ResolveCmd(root, ctx)
 
+	if c.helpFlag(ctx) {
+		if c.H == nil {
+			return fmt.Errorf("no helper configured on commander")
+		}
+		fmt.Fprintf(ctx.Out, "%s\n", c.H.Help(conq.HelpSubject{Cmd: ctx.Path[len(ctx.Path)-1], Ctx: &ctx}))
+		return nil
+	}
+	if version := ctx.Path.Version(); version != "" && c.versionFlag(ctx) {
+		fmt.Fprintf(ctx.Out, "%s %s\n", ctx.Path[0].Name, version)
+		return nil
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(ctx.Path) - 1; i >= 0 && err != nil; i-- {
+			if h := ctx.Path[i].Hooks.OnError; h != nil {
+				err = h(ctx, err)
+			}
+		}
+	}()
+
+	for _, x := range ctx.Path {
+		if h := x.Hooks.PreExtract; h != nil {
+			if ctx, err = h(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx, err = c.extract(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, x := range ctx.Path {
+		if h := x.Hooks.PreRun; h != nil {
+			if ctx, err = h(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	cmd := ctx.Path[len(ctx.Path)-1]
+	if cmd.Run == nil {
+		var pth strings.Builder
+		pth.WriteString(ctx.Path[0].Name)
+		for _, x := range ctx.Path[1:] {
+			fmt.Fprintf(&pth, " %s", x.Name)
+		}
+		return fmt.Errorf("would've run %q, but no Run function defined", pth.String())
+	}
+
+	run := cmd.Run
+	for i := len(ctx.Path) - 1; i >= 0; i-- {
+		if w := ctx.Path[i].Hooks.Wrap; w != nil {
+			run = w(run)
+		}
+	}
+	for i := len(c.M) - 1; i >= 0; i-- {
+		run = c.M[i](run)
+	}
+
+	return run(ctx)
+}
+
+// extract the options, environment and positional arguments of the leaf-command
+// in ctx.Path into ctx.Values and validate their presence.
+func (c Commander) extract(ctx conq.Ctx) (conq.Ctx, error) {
 	cmd := ctx.Path[len(ctx.Path)-1]
 	ctx, err := c.O.ExtractOptions(ctx, cmd.Opts...)
 	if err != nil {
-		return fmt.Errorf("failed extracting options: %w", err)
+		return ctx, fmt.Errorf("failed extracting options: %w", err)
 	}
 
 	for _, opt := range cmd.Opts {
 		o := opt.Opt()
-		if !o.Require {
-			continue
+		_, ok := ctx.Values[o.Name]
+		if !ok && o.Default != "" {
+			if err := setDefault(ctx, o); err != nil {
+				return ctx, err
+			}
+			ok = true
 		}
-		if _, ok := ctx.Values[o.Name]; !ok {
-			return fmt.Errorf("missing required option %q", o.Name)
+		if o.Require && !ok {
+			return ctx, fmt.Errorf("missing required option %q", o.Name)
 		}
 	}
 
 	for _, opt := range cmd.Env {
 		o := opt.Opt()
 		envTxt, ok := os.LookupEnv(o.Name)
+		if !ok && o.Default != "" {
+			envTxt, ok = o.Default, true
+		}
 		if !ok {
 			if o.Require {
-				return fmt.Errorf("missing required environment-variable: %q", o.Name)
+				return ctx, fmt.Errorf("missing required environment-variable: %q", o.Name)
 			}
 			continue
 		}
@@ -67,7 +177,7 @@ func (c Commander) Execute(root *conq.Cmd, ctx conq.Ctx) error {
 		}
 		val, err := o.Parse(envTxt)
 		if err != nil {
-			return fmt.Errorf("failed parsing environment variable %s: %w", o.Name, err)
+			return ctx, fmt.Errorf("failed parsing environment variable %s: %w", o.Name, err)
 		}
 
 		ctx.Strings[o.Name] = envTxt
@@ -77,30 +187,42 @@ func (c Commander) Execute(root *conq.Cmd, ctx conq.Ctx) error {
 	for i, arg := range cmd.Args {
 		o := arg.Opt()
 		if len(ctx.Args) == 0 {
+			if o.Default != "" {
+				if err := setDefault(ctx, o); err != nil {
+					return ctx, err
+				}
+				continue
+			}
 			if o.Require {
-				return fmt.Errorf("missing required positional argument at position %d %q", i+1, o.Name)
+				return ctx, fmt.Errorf("missing required positional argument at position %d %q", i+1, o.Name)
 			}
 			break
 		}
 
 		val, err := o.Parse(ctx.Args[0])
 		if err != nil {
-			return fmt.Errorf("failed parsing argument %d %q: %w", i+1, o.Name, err)
+			return ctx, fmt.Errorf("failed parsing argument %d %q: %w", i+1, o.Name, err)
 		}
 		ctx.Values[o.Name] = val
 		ctx.Args = ctx.Args[1:]
 	}
 
-	if cmd.Run == nil {
-		var pth strings.Builder
-		pth.WriteString(ctx.Path[0].Name)
-		for _, x := range ctx.Path[1:] {
-			fmt.Fprintf(&pth, " %s", x.Name)
-		}
-		return fmt.Errorf("would've run %q, but no Run function defined", pth.String())
-	}
+	return ctx, nil
+}
 
-	return cmd.Run(ctx)
+// setDefault stores the O.Default of o in ctx, as if it was given.
+func setDefault(ctx conq.Ctx, o conq.O) error {
+	ctx.Strings[o.Name] = o.Default
+	if o.Parse == nil {
+		ctx.Values[o.Name] = o.Default
+		return nil
+	}
+	val, err := o.Parse(o.Default)
+	if err != nil {
+		return fmt.Errorf("failed parsing default of %q: %w", o.Name, err)
+	}
+	ctx.Values[o.Name] = val
+	return nil
 }
 
 // Path should always include the root command and the leaf-command that's being executed
@@ -124,3 +246,127 @@ a:
 	}
 	return oc
 }
+
+// helpFlag reports whether -h or --help is given to the invoked command, which
+// neither declares an option of that name nor opted out using NoHelpFlag.
+func (c Commander) helpFlag(ctx conq.Ctx) bool {
+	cmd := ctx.Path[len(ctx.Path)-1]
+	if cmd.Help.NoHelpFlag {
+		return false
+	}
+	return hasFlag(cmd, ctx.Args, func(arg string) bool {
+		return arg == "-h" && !declares(cmd, "h") || arg == "--help" && !declares(cmd, "help")
+	})
+}
+
+// versionFlag reports whether --version is given to the invoked command, which
+// doesn't declare an option of that name.
+func (c Commander) versionFlag(ctx conq.Ctx) bool {
+	cmd := ctx.Path[len(ctx.Path)-1]
+	if declares(cmd, "version") {
+		return false
+	}
+	return hasFlag(cmd, ctx.Args, func(arg string) bool { return arg == "--version" })
+}
+
+// hasFlag reports whether match accepts one of the options in args.  Like getopt,
+// it stops at the first positional argument or "--" and skips the values of
+// cmd's options, so `--pattern -h` passes "-h" as the value of --pattern.
+func hasFlag(cmd *conq.Cmd, args []string, match func(string) bool) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--" || !strings.HasPrefix(arg, "-"):
+			return false
+		case match(arg):
+			return true
+		case takesValue(cmd, arg):
+			i++
+		}
+	}
+	return false
+}
+
+// takesValue reports whether arg is an option of cmd, which is followed by it's
+// value as the next argument.
+func takesValue(cmd *conq.Cmd, arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+	name := strings.TrimLeft(arg, "-")
+	for _, opt := range cmd.Opts {
+		o := opt.Opt()
+		for _, n := range strings.Split(o.Name, ",") {
+			if n == name {
+				return o.Type == nil || o.Type.Kind() != reflect.Bool
+			}
+		}
+	}
+	return false
+}
+
+// colorFlag finds and removes the last --color option from args.  Like
+// ResolveCmd, it descends into the subcommands named in args and stops at the
+// first positional argument or "--", so the words bash's `complete -C` passes
+// to the completion command aren't mistaken for its value.  Invalid values are
+// reported in err, as they are an error unless the invoked command declares
+// it's own color option.
+func colorFlag(root *conq.Cmd, args []string) (rest []string, mode conq.ColorMode, ok bool, err error) {
+	rest = make([]string, 0, len(args))
+	cmd := root
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var val string
+		switch {
+		case arg == "--":
+			return append(rest, args[i:]...), mode, ok, err
+		case !strings.HasPrefix(arg, "-"):
+			sub := subcommand(cmd, arg)
+			if sub == nil {
+				return append(rest, args[i:]...), mode, ok, err
+			}
+			cmd = sub
+			rest = append(rest, arg)
+			continue
+		case strings.HasPrefix(arg, "--color="):
+			val = arg[len("--color="):]
+		case arg == "--color" && i+1 < len(args):
+			i++
+			val = args[i]
+		default:
+			rest = append(rest, arg)
+			if takesValue(cmd, arg) && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+		ok = true
+		if err = mode.UnmarshalText([]byte(val)); err != nil {
+			err = fmt.Errorf("invalid --color option: %w", err)
+		}
+	}
+	return
+}
+
+// subcommand returns the subcommand of cmd called name, or nil.
+func subcommand(cmd *conq.Cmd, name string) *conq.Cmd {
+	for _, x := range cmd.Commands {
+		if x.Name == name {
+			return x
+		}
+	}
+	return nil
+}
+
+// declares reports whether cmd has an option named name.
+func declares(cmd *conq.Cmd, name string) bool {
+	for _, opt := range cmd.Opts {
+		for _, n := range strings.Split(opt.Opt().Name, ",") {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
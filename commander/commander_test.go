@@ -1,9 +1,16 @@
 package commander
 
 import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/getopt"
+	"github.com/posener/complete"
 )
 
 func TestResolveNestedSubcommand(t *testing.T) {
@@ -30,3 +37,455 @@ func TestResolveNestedSubcommand(t *testing.T) {
 		t.Error("wrong command resolved")
 	}
 }
+
+func TestHookOrder(t *testing.T) {
+	var trace []string
+	hooks := func(name string) conq.Hooks {
+		return conq.Hooks{
+			PreExtract: func(c conq.Ctx) (conq.Ctx, error) {
+				trace = append(trace, name+".PreExtract")
+				return c, nil
+			},
+			PreRun: func(c conq.Ctx) (conq.Ctx, error) {
+				trace = append(trace, name+".PreRun")
+				c.Values[name] = true
+				return c, nil
+			},
+			Wrap: func(next func(conq.Ctx) error) func(conq.Ctx) error {
+				return func(c conq.Ctx) error {
+					trace = append(trace, name+".Wrap")
+					return next(c)
+				}
+			},
+			OnError: func(c conq.Ctx, err error) error {
+				trace = append(trace, name+".OnError")
+				return fmt.Errorf("%s: %w", name, err)
+			},
+		}
+	}
+
+	errRun := errors.New("run failed")
+	leaf := &conq.Cmd{
+		Name:  "leaf",
+		Hooks: hooks("leaf"),
+		Run: func(c conq.Ctx) error {
+			if c.Values["root"] != true || c.Values["leaf"] != true {
+				t.Error("values set by PreRun hooks missing")
+			}
+			trace = append(trace, "Run")
+			return errRun
+		},
+	}
+	root := &conq.Cmd{Name: "root", Hooks: hooks("root"), Commands: []*conq.Cmd{leaf}}
+
+	cmdr := New(getopt.New(), nil)
+	cmdr.M = []conq.Middleware{func(next func(conq.Ctx) error) func(conq.Ctx) error {
+		return func(c conq.Ctx) error {
+			trace = append(trace, "M")
+			return next(c)
+		}
+	}}
+
+	ctx := conq.OSContext("leaf")
+	err := cmdr.Execute(root, ctx)
+	if !errors.Is(err, errRun) {
+		t.Fatalf("expected run error, got %v", err)
+	}
+	if err.Error() != "root: leaf: run failed" {
+		t.Errorf("unexpected error %q", err)
+	}
+
+	expect := []string{
+		"root.PreExtract", "leaf.PreExtract",
+		"root.PreRun", "leaf.PreRun",
+		"M", "root.Wrap", "leaf.Wrap", "Run",
+		"leaf.OnError", "root.OnError",
+	}
+	if strings.Join(trace, " ") != strings.Join(expect, " ") {
+		t.Errorf("expected hooks in order\n%v\ngot\n%v", expect, trace)
+	}
+}
+
+func TestOnErrorHandled(t *testing.T) {
+	var called []string
+	onError := func(name string, handle bool) func(conq.Ctx, error) error {
+		return func(_ conq.Ctx, err error) error {
+			if err == nil {
+				t.Errorf("%s.OnError called without error", name)
+			}
+			called = append(called, name)
+			if handle {
+				return nil
+			}
+			return err
+		}
+	}
+	leaf := &conq.Cmd{
+		Name:  "leaf",
+		Hooks: conq.Hooks{OnError: onError("leaf", true)},
+		Run:   func(conq.Ctx) error { return errors.New("run failed") },
+	}
+	root := &conq.Cmd{
+		Name:     "root",
+		Hooks:    conq.Hooks{OnError: onError("root", false)},
+		Commands: []*conq.Cmd{leaf},
+	}
+
+	if err := New(getopt.New(), nil).Execute(root, conq.OSContext("leaf")); err != nil {
+		t.Errorf("expected the error to be handled, got %v", err)
+	}
+	if strings.Join(called, " ") != "leaf" {
+		t.Errorf("expected only leaf.OnError to be called, got %v", called)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	optDepth := conq.ReqOpt[int]{Name: "depth", Default: "3"}
+	optMode := conq.Opt[string]{Name: "mode", Default: "fast"}
+	argDir := conq.Opt[string]{Name: "dir", Default: "."}
+	envHome := conq.Opt[string]{Name: "CONQ_TEST_HOME", Default: "/home"}
+	var got string
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{optDepth, optMode},
+		Args: conq.Opts{argDir},
+		Env:  conq.Opts{envHome},
+		Run: func(c conq.Ctx) error {
+			depth := optDepth.Get(c)
+			mode, _ := optMode.Get(c)
+			dir, _ := argDir.Get(c)
+			got = fmt.Sprintf("%d %s %s %s", depth, mode, dir, c.Strings[envHome.Name])
+			return nil
+		},
+	}
+	cmdr := New(getopt.New(), nil)
+
+	tests := []struct{ args, expect string }{
+		{"", "3 fast . /home"},
+		{"--depth 1 --mode slow src", "1 slow src /home"},
+	}
+	for _, tt := range tests {
+		ctx := conq.OSContext()
+		ctx.Args = strings.Fields(tt.args)
+		if err := cmdr.Execute(root, ctx); err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+		if got != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.args, tt.expect, got)
+		}
+	}
+
+	t.Setenv(envHome.Name, "/root")
+	ctx := conq.OSContext()
+	ctx.Args = nil
+	if err := cmdr.Execute(root, ctx); err != nil || got != "3 fast . /root" {
+		t.Errorf("expected the environment to override the default, got %q (%v)", got, err)
+	}
+}
+
+type pathHelper struct{}
+
+func (pathHelper) Help(sub conq.HelpSubject) string {
+	return "help " + sub.Cmd.Name
+}
+
+func TestHelpFlag(t *testing.T) {
+	var ran bool
+	optPath := conq.ReqOpt[string]{Name: "path"}
+	leaf := &conq.Cmd{Name: "leaf", Opts: conq.Opts{optPath}, Run: func(conq.Ctx) error {
+		ran = true
+		return nil
+	}}
+	raw := &conq.Cmd{Name: "raw", Help: conq.CmdHelp{NoHelpFlag: true}, Run: func(conq.Ctx) error {
+		ran = true
+		return nil
+	}}
+	root := &conq.Cmd{Name: "root", Commands: []*conq.Cmd{leaf, raw}}
+	cmdr := New(getopt.New(), pathHelper{})
+
+	tests := []struct {
+		args   string
+		expect string
+		ran    bool
+	}{
+		{"leaf -h", "help leaf\n", false},
+		{"leaf --help", "help leaf\n", false},
+		{"--help leaf", "help root\n", false},
+		{"leaf --path -h", "", true},
+		{"leaf --path x arg --help", "", true},
+		{"raw -h", "", true},
+	}
+	for _, tt := range tests {
+		ran = false
+		var out strings.Builder
+		ctx := conq.OSContext(strings.Fields(tt.args)...)
+		ctx.Out = &out
+		if err := cmdr.Execute(root, ctx); err != nil {
+			t.Errorf("%q: unexpected error %v", tt.args, err)
+		}
+		if out.String() != tt.expect || ran != tt.ran {
+			t.Errorf("%q: expected output %q and run %v, got %q and %v", tt.args, tt.expect, tt.ran, out.String(), ran)
+		}
+	}
+}
+
+// bash's `complete -C` passes the word and the one before it after the line, of
+// which only the options before the first positional are taken for --color.
+func TestBashCompletionColor(t *testing.T) {
+	root := &conq.Cmd{
+		Name:     "app",
+		Opts:     conq.Opts{conq.Opt[bool]{Name: "colorful"}},
+		Commands: []*conq.Cmd{CmdCompletion},
+	}
+	cmdr := New(getopt.New(), nil)
+
+	t.Setenv("COMP_LINE", "app --color")
+	t.Setenv("COMP_POINT", "11")
+	t.Setenv("COMP_TYPE", "9")
+	var out strings.Builder
+	ctx := conq.OSContext("completion", "app", "--color", "app")
+	ctx.Out = &out
+	if err := cmdr.Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "--colorful\n" {
+		t.Errorf("expected --colorful, got %q", out.String())
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{
+			conq.Opt[string]{Name: "mode,m", Description: "how to run", Predict: complete.PredictSet("fast", "slow")},
+		},
+		Commands: []*conq.Cmd{CmdCompletion},
+	}
+	cmds := &conq.Cmd{
+		Name: "app",
+		Commands: []*conq.Cmd{
+			{Name: "serve", Help: conq.CmdHelp{Summary: "serve it"}},
+			{Name: "secret", Help: conq.CmdHelp{Hidden: true}},
+			CmdCompletion,
+		},
+	}
+	cmdr := New(getopt.New(), nil)
+
+	compl := func(root *conq.Cmd, line string) string {
+		t.Setenv("COMP_LINE", line)
+		t.Setenv("COMP_POINT", fmt.Sprint(len(line)))
+		t.Setenv("COMP_TYPE", "9")
+		var out strings.Builder
+		ctx := conq.OSContext("completion", "zsh")
+		ctx.Out = &out
+		if err := cmdr.Execute(root, ctx); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	tests := []struct {
+		root         *conq.Cmd
+		line, expect string
+	}{
+		{root, "app --mo", "option\t--mode\thow to run\n"},
+		{cmds, "app se", "command\tserve\tserve it\n"},
+		{root, "app --mode=f", "value\tfast\t\n"},
+	}
+	for _, tt := range tests {
+		if got := compl(tt.root, tt.line); got != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.line, tt.expect, got)
+		}
+	}
+
+	t.Setenv("COMP_LINE", "")
+	var out strings.Builder
+	ctx := conq.OSContext("completion", "zsh")
+	ctx.Out = &out
+	if err := cmdr.Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "#compdef app\n") || !strings.Contains(out.String(), "app completion zsh") {
+		t.Errorf("unexpected zsh script:\n%s", out.String())
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{
+			conq.Opt[string]{Name: "mode,m", Description: "how to run", Predict: complete.PredictSet("fast", "slow")},
+			conq.Opt[string]{Name: "say", Predict: complete.PredictSet("it's", "its")},
+		},
+		Commands: []*conq.Cmd{CmdCompletion},
+	}
+	cmds := &conq.Cmd{Name: "app", Commands: []*conq.Cmd{{Name: "serve"}, CmdCompletion}}
+	cmdr := New(getopt.New(), nil)
+
+	tests := []struct {
+		root         *conq.Cmd
+		line, expect string
+	}{
+		{root, "app --mo", "--mode\thow to run\n"},
+		{cmds, "app se", "serve\n"},
+		{root, "app --mode=f", "--mode=fast\n"},
+		{root, `app --say 'it\'`, "it's\n"},
+	}
+	for _, tt := range tests {
+		t.Setenv("COMP_LINE", tt.line)
+		t.Setenv("COMP_TYPE", "9")
+		var out strings.Builder
+		ctx := conq.OSContext("completion", "fish")
+		ctx.Out = &out
+		if err := cmdr.Execute(tt.root, ctx); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.line, tt.expect, out.String())
+		}
+	}
+}
+
+// powershell simulates the script of `completion powershell` completing typed
+// with the cursor at it's end: the command text has trailing whitespace
+// trimmed and the output is mapped to CompletionResult types and tooltips.
+func powershell(t *testing.T, cmdr Commander, root *conq.Cmd, typed string) []string {
+	var word string
+	if !strings.HasSuffix(typed, " ") {
+		fields := strings.Fields(typed)
+		word = fields[len(fields)-1]
+	}
+	t.Setenv("COMP_LINE", strings.TrimRight(typed, " "))
+	t.Setenv("COMP_POINT", fmt.Sprint(len(utf16.Encode([]rune(typed)))))
+	t.Setenv("COMP_WORD", word)
+	t.Setenv("COMP_TYPE", "9")
+
+	var out strings.Builder
+	ctx := conq.OSContext("completion", "powershell")
+	ctx.Out = &out
+	if err := cmdr.Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []string
+	for _, l := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		if l == "" {
+			continue
+		}
+		parts := strings.SplitN(l, "\t", 3)
+		typ := map[string]string{"option": "ParameterName", "command": "Command"}[parts[0]]
+		if typ == "" {
+			typ = "ParameterValue"
+		}
+		tooltip := parts[2]
+		if tooltip == "" {
+			tooltip = parts[1]
+		}
+		results = append(results, fmt.Sprintf("%s %s %s", typ, parts[1], tooltip))
+	}
+	return results
+}
+
+func TestPowershellCompletion(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{
+			conq.Opt[string]{Name: "mode,m", Description: "how", Predict: complete.PredictSet("fast", "slow", "🐢turtle")},
+			conq.Opt[string]{Name: "path", Description: "where", Predict: complete.PredictSet(`C:\Users\me`, `C:\Windows`)},
+		},
+		Commands: []*conq.Cmd{CmdCompletion},
+	}
+	cmds := &conq.Cmd{
+		Name: "app",
+		Commands: []*conq.Cmd{
+			{Name: "serve", Help: conq.CmdHelp{Summary: "serve it"}, Commands: []*conq.Cmd{{Name: "now"}}},
+			CmdCompletion,
+		},
+	}
+	cmdr := New(getopt.New(), nil)
+
+	tests := []struct {
+		root   *conq.Cmd
+		typed  string
+		expect []string
+	}{
+		{root, "app --mo", []string{"ParameterName --mode how"}},
+		{cmds, "app s", []string{"Command serve serve it"}},
+		{cmds, "app serve ", []string{"Command now now"}},
+		{root, "app --mode=s", []string{"ParameterValue --mode=slow --mode=slow"}},
+		{root, "app --mode=🐢", []string{"ParameterValue --mode=🐢turtle --mode=🐢turtle"}},
+		{root, `app --path C:\Users\m`, []string{`ParameterValue C:\Users\me C:\Users\me`}},
+		{root, `app --path 'C:\Users\m`, []string{`ParameterValue C:\Users\me C:\Users\me`}},
+	}
+	for _, tt := range tests {
+		got := powershell(t, cmdr, tt.root, tt.typed)
+		if strings.Join(got, "|") != strings.Join(tt.expect, "|") {
+			t.Errorf("%q: expected %q, got %q", tt.typed, tt.expect, got)
+		}
+	}
+
+	t.Setenv("COMP_LINE", "")
+	var out strings.Builder
+	ctx := conq.OSContext("completion", "powershell")
+	ctx.Out = &out
+	if err := cmdr.Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Register-ArgumentCompleter -Native -CommandName 'app'") ||
+		!strings.Contains(out.String(), "& 'app' completion powershell") {
+		t.Errorf("unexpected powershell script:\n%s", out.String())
+	}
+}
+
+func TestStaticBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{
+			conq.Opt[string]{Name: "mode,m", Predict: complete.PredictSet("fast", "slow")},
+			conq.Opt[bool]{Name: "verbose"},
+		},
+		Commands: []*conq.Cmd{
+			{Name: "serve", Commands: []*conq.Cmd{{Name: "now"}}},
+			{Name: "secret", Help: conq.CmdHelp{Hidden: true}},
+		},
+	}
+	var script strings.Builder
+	if err := WriteStaticBash(&script, root, "app completion"); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulates bash setting COMP_WORDS and COMP_CWORD, without splitting at
+	// COMP_WORDBREAKS
+	script.WriteString(`
+t() {
+	read -ra COMP_WORDS <<<"$1"
+	[[ $1 == *" " ]] && COMP_WORDS+=("")
+	COMP_CWORD=$((${#COMP_WORDS[@]} - 1))
+	COMPREPLY=()
+	_app
+	echo "${COMPREPLY[*]}"
+}
+`)
+	tests := []struct{ line, expect string }{
+		{"app --m", "--mode"},
+		{"app -m ", "fast slow"},
+		{"app s", "serve"},
+		{"app serve ", "now"},
+		{"app ", "--mode -m --verbose serve"},
+		{"app --verbose ", "--mode -m --verbose"},
+		{"app serve now ", ""},
+	}
+	for _, tt := range tests {
+		out, err := exec.Command(bash, "-c", script.String()+fmt.Sprintf("t %q", tt.line)).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%q: %v: %s", tt.line, err, out)
+		}
+		if got := strings.TrimSpace(string(out)); got != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.line, tt.expect, got)
+		}
+	}
+}
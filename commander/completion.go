@@ -4,33 +4,33 @@ import (
 	"fmt"
 	"os"
 	"strconv"
-	"strings"
-	"unicode"
 
+	"github.com/patroclos/go-conq/completion"
 	"github.com/posener/complete"
 )
 
 type completionCtx struct {
 	line  string
 	point int
-	t     comptype
+	t     CompType
 }
 
-type comptype int
+// CompType is the kind of completion bash attempts, passed in COMP_TYPE.
+type CompType int
 
-func (t comptype) String() string {
+func (t CompType) String() string {
 	return fmt.Sprintf("COMP_TYPE='%c'", t)
 }
 
 const (
-	ctNormal = comptype('\t')
-	ctSucc   = comptype('?')
-	ctAlt    = comptype('!')
-	ctMod    = comptype('@')
-	ctMenu   = comptype('%')
+	CompNormal       = CompType('\t')
+	CompSuccessive   = CompType('?')
+	CompAlternatives = CompType('!')
+	CompUnmodified   = CompType('@')
+	CompMenu         = CompType('%')
 )
 
-func completionContext() (line string, point int, ctype comptype, ok bool) {
+func completionContext() (line string, point int, ctype CompType, ok bool) {
 	line = os.Getenv("COMP_LINE")
 	if line == "" {
 		return
@@ -45,35 +45,26 @@ func completionContext() (line string, point int, ctype comptype, ok bool) {
 	if err != nil {
 		return
 	}
-	ctype = comptype(tint)
+	ctype = CompType(tint)
 	ok = true
 	return
 }
 
-func complArgs(line string) complete.Args {
+// complArgs splits line by the quoting rules of syntax into the shell words of
+// the complete.Args, with the unquoted prefix of the word under the cursor at
+// it's end as Last.
+func complArgs(line string, syntax completion.Syntax) complete.Args {
+	l := syntax.Lex(line, len(line))
+
 	var (
 		all       []string
 		completed []string
-		last      string
 		lastComp  string
 	)
-	parts := strings.Fields(line)
-
-	if len(line) > 0 && unicode.IsSpace(rune(line[len(line)-1])) {
-		parts = append(parts, "")
-	}
-
-	if len(parts) > 0 {
-		all = parts[1:]
-		if len(all) > 0 {
-			completed = all[:len(all)-1]
-		}
+	if len(l.Words) > 0 {
+		completed = l.Words[1:]
+		all = append(completed[:len(completed):len(completed)], l.Prefix)
 	}
-
-	if len(parts) > 0 {
-		last = parts[len(parts)-1]
-	}
-
 	if len(completed) > 0 {
 		lastComp = completed[len(completed)-1]
 	}
@@ -81,7 +72,7 @@ func complArgs(line string) complete.Args {
 	return complete.Args{
 		All:           all,
 		Completed:     completed,
-		Last:          last,
+		Last:          l.Prefix,
 		LastCompleted: lastComp,
 	}
 }
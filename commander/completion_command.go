@@ -1,67 +1,207 @@
 package commander
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/patroclos/go-conq"
 	"github.com/patroclos/go-conq/completion"
+	"github.com/posener/complete"
 )
 
+// CmdCompletion implements bash's `complete -C` protocol.  It's subcommands
+// implement the protocols of other shells.  Outside of completion mode, they
+// print the shell-code installing the completion, or with --static, a script
+// that doesn't run the binary for every completion.
 var CmdCompletion *conq.Cmd = &conq.Cmd{
-	Name: "completion",
+	Name:     "completion",
+	Commands: []*conq.Cmd{cmdZsh, cmdFish, cmdPowershell},
+	Opts:     conq.Opts{optStatic},
+	Help:     conq.CmdHelp{Summary: "shell completion", NoHelpFlag: true},
 	Run: func(c conq.Ctx) error {
 		line, point, ctype, ok := completionContext()
-		// in completion mode, show install instructions
 		if ok {
-			return doCompletion(c.Com, c.Path[0], line, point, ctype)
+			return Complete(c, c.Path[0], line, point, ctype)
 		}
 
 		// show some installation instructions and exit
-		var pth strings.Builder
-		pth.WriteString(c.Path[0].Name)
-		for _, x := range c.Path[1:] {
-			fmt.Fprintf(&pth, " %s", x.Name)
+		name, pth := c.Path[0].Name, cmdline(c.Path)
+		if static, _ := optStatic.Get(c); static {
+			return WriteStaticBash(c.Out, c.Path[0], pth)
 		}
-		pth.WriteString(" completion")
-
-		fmt.Fprintf(c.Out, "complete -C %q %s\n", pth.String(), c.Path[0].Name)
+		fmt.Fprintf(c.Out, "complete -C %q %s\n", pth, name)
+		fmt.Fprintf(c.Out, "# zsh:  source <(%s zsh)\n", pth)
+		fmt.Fprintf(c.Out, "# fish: %s fish | source\n", pth)
+		fmt.Fprintf(c.Out, "# pwsh: %s powershell | Out-String | Invoke-Expression\n", pth)
 		return nil
 	},
 }
 
-// TODO: put completion into a subcommand, so its entirely optional and can be custom mounted so to speak
-// TODO: look at cobras custom ctype handline, do we need it aswell? do we want our own customizations?
-func doCompletion(com conq.Commander, cmd *conq.Cmd, line string, point int, ctype comptype) error {
+// Complete writes the candidates for the word before point in line, a command
+// line of the tree at root, to c.Out.  The candidates are written one per line
+// and quoted for insertion, as bash's `complete -C` expects them.  It uses c.Com
+// to resolve commands and complete options.  All completion types complete
+// alike for now.
+func Complete(c conq.Ctx, root *conq.Cmd, line string, point int, ctype CompType) error {
+	// bash inserts the candidates as they are
+	l := completion.Lex(line, point)
+	for _, cand := range candidates(c, root, line, point, completion.Bash) {
+		if _, err := fmt.Fprintln(c.Out, l.Requote(cand.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// candidate for the word being completed.
+type candidate struct {
+	value string
+	desc  string
+	group string
+}
+
+// groups of candidates, shells able to do so list them separately.
+const (
+	groupValue   = "value"
+	groupOption  = "option"
+	groupCommand = "command"
+)
+
+// candidates returns the completions for the word ending at point in line,
+// which starts with the name of root and is split by the quoting rules of
+// syntax, in the context c.
+func candidates(c conq.Ctx, root *conq.Cmd, line string, point int, syntax completion.Syntax) []candidate {
+	// TODO: look at cobras custom ctype handline, do we need it aswell? do we want our own customizations?
 	if point >= 0 && point < len(line) {
 		line = line[:point]
 	}
 
-	a := complArgs(line)
+	a := complArgs(line, syntax)
 
-	coco := conq.OSContext()
+	com := c.Com
+	coco := c
 	coco.Args = a.Completed
-	coco = com.ResolveCmd(cmd, coco)
+	coco = com.ResolveCmd(root, coco)
 
 	// subcommand completion
 	a = sliceArgs(a, len(coco.Path)-1)
-	cc := completion.Context{
-		Args: a,
+	leaf := coco.Path[len(coco.Path)-1]
+
+	// the options given before the word being completed, for the predictors
+	cc, names := com.Optioner().CompleteOptions(coco, a, leaf.Opts...)
+
+	if strings.HasPrefix(a.Last, "-") && strings.Contains(a.Last, "=") {
+		return assignment(cc, a)
 	}
-	var options []string = com.Optioner().CompleteOptions(cc, cmd.Opts...)
-	if len(options) == 0 {
-		for _, sub := range cmd.Commands {
-			options = append(options, sub.Name)
+
+	// the completed arguments after the command path tell, which slot the
+	// cursor is in: the value of an option, or the next positional argument
+	ext, err := com.Optioner().ExtractOptions(coco, leaf.Opts...)
+	valueSlot := errors.Is(err, conq.ErrMissingValue)
+	ext.Values, ext.Strings = cc.Values, cc.Strings
+
+	var cands []candidate
+	for _, name := range names {
+		if o, ok := option(leaf, strings.TrimLeft(name, "-")); ok && !valueSlot && strings.HasPrefix(name, "-") {
+			cands = append(cands, candidate{name, o.Description, groupOption})
+			continue
 		}
+		cands = append(cands, candidate{value: name, group: groupValue})
+	}
+	if valueSlot {
+		return filter(cands, a.Last)
 	}
 
-	for _, opt := range options {
-		if !strings.HasPrefix(opt, a.Last) {
-			continue
+	// subcommands are only resolved directly after their parent
+	if len(a.Completed) == 0 {
+		for _, sub := range leaf.Commands {
+			if sub.Help.Hidden {
+				continue
+			}
+			cands = append(cands, candidate{sub.Name, sub.Help.Summary, groupCommand})
+		}
+	}
+	// arguments past the last positional are left in Ctx.Args for the command
+	// to consume, so it's predictor completes them as well
+	if n := len(ext.Args); err == nil && len(leaf.Args) > 0 {
+		if n >= len(leaf.Args) {
+			n = len(leaf.Args) - 1
+		}
+		for _, v := range conq.Predict(leaf.Args[n].Opt().Predict, ext, a) {
+			cands = append(cands, candidate{value: v, group: groupValue})
+		}
+	}
+
+	return filter(cands, a.Last)
+}
+
+// assignment completes the value of a --opt=value argument.  Only the value is
+// returned, as bash and the other shells' scripts treat '=' as a word-break.
+func assignment(c conq.Ctx, a complete.Args) []candidate {
+	i := strings.Index(a.Last, "=")
+	o, ok := option(c.Path[len(c.Path)-1], strings.TrimLeft(a.Last[:i], "-"))
+	if !ok {
+		return nil
+	}
+	a.Last = a.Last[i+1:]
+	var cands []candidate
+	for _, v := range conq.Predict(o.Predict, c, a) {
+		cands = append(cands, candidate{value: v, group: groupValue})
+	}
+	return filter(cands, a.Last)
+}
+
+// assignPrefix returns the "--opt=" of a word assigning an option value, for
+// shells replacing the whole word with the candidate.
+func assignPrefix(word string) string {
+	if !strings.HasPrefix(word, "-") || !strings.Contains(word, "=") {
+		return ""
+	}
+	return word[:strings.Index(word, "=")+1]
+}
+
+// option finds the option of cmd with the given name or alias.
+func option(cmd *conq.Cmd, name string) (conq.O, bool) {
+	for _, opt := range cmd.Opts {
+		o := opt.Opt()
+		for _, n := range strings.Split(o.Name, ",") {
+			if n == name {
+				return o, true
+			}
+		}
+	}
+	return conq.O{}, false
+}
+
+func filter(cands []candidate, prefix string) []candidate {
+	var out []candidate
+	for _, c := range cands {
+		if strings.HasPrefix(c.value, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// cmdline joins the names along pth.
+func cmdline(pth conq.Pth) string {
+	names := make([]string, len(pth))
+	for i, x := range pth {
+		names[i] = x.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// writeCandidates writes one tab-separated "group value description" line
+// per candidate, which the scripts of shells supporting descriptions parse.
+func writeCandidates(w io.Writer, cands []candidate) error {
+	for _, c := range cands {
+		desc := strings.ReplaceAll(c.desc, "\n", " ")
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", c.group, c.value, desc); err != nil {
+			return err
 		}
-		// TODO: return values and let CmdCompletion.Run use the context-utilities
-		// to print and filter options.
-		fmt.Println(opt)
 	}
 	return nil
 }
@@ -0,0 +1,56 @@
+package commander
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/completion"
+)
+
+var cmdFish *conq.Cmd = &conq.Cmd{
+	Name: "fish",
+	Help: conq.CmdHelp{Summary: "fish completion", NoHelpFlag: true},
+	Run: func(c conq.Ctx) error {
+		line, point, _, ok := completionContext()
+		if !ok {
+			err := fishScript.Execute(c.Out, map[string]string{
+				"Name":     c.Path[0].Name,
+				"Func":     "__" + identifier(c.Path[0].Name) + "_complete",
+				"Complete": cmdline(c.Path),
+			})
+			if err != nil {
+				return fmt.Errorf("failed writing fish completion script: %w", err)
+			}
+			return nil
+		}
+
+		// fish replaces the whole token, so the --opt= of an assignment is put
+		// back in front of it's values.
+		if point >= 0 && point < len(line) {
+			line = line[:point]
+		}
+		prefix := assignPrefix(complArgs(line, completion.Fish).Last)
+		for _, cand := range candidates(c, c.Path[0], line, len(line), completion.Fish) {
+			value := cand.value
+			if cand.group == groupValue {
+				value = prefix + value
+			}
+			desc := strings.ReplaceAll(cand.desc, "\n", " ")
+			if desc == "" {
+				fmt.Fprintln(c.Out, value)
+				continue
+			}
+			fmt.Fprintf(c.Out, "%s\t%s\n", value, desc)
+		}
+		return nil
+	},
+}
+
+// fishScript calls back into the binary with the commandline up to the cursor.
+var fishScript = template.Must(template.New("fish").Parse(`function {{.Func}}
+	env COMP_LINE=(commandline -cp) COMP_TYPE=9 {{.Complete}} 2>/dev/null
+end
+complete -c {{.Name}} -f -a '({{.Func}})'
+`))
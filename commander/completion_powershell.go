@@ -0,0 +1,76 @@
+package commander
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"unicode/utf16"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/completion"
+)
+
+// cmdPowershell implements the protocol of the Register-ArgumentCompleter
+// script below.  The script passes the text of the command (COMP_LINE), the
+// cursor position relative to it's start (COMP_POINT) and the word being
+// completed (COMP_WORD), no AST.  Powershell trims trailing whitespace off the
+// command text, so a cursor past it's end means a new word is started.
+var cmdPowershell *conq.Cmd = &conq.Cmd{
+	Name: "powershell",
+	Help: conq.CmdHelp{Summary: "powershell completion", NoHelpFlag: true},
+	Run: func(c conq.Ctx) error {
+		line, point, _, ok := completionContext()
+		if !ok {
+			err := powershellScript.Execute(c.Out, map[string]string{
+				"Name": c.Path[0].Name,
+				"Args": cmdline(c.Path[1:]),
+			})
+			if err != nil {
+				return fmt.Errorf("failed writing powershell completion script: %w", err)
+			}
+			return nil
+		}
+
+		// the cursor position counts the UTF-16 code units of .NET strings
+		units := utf16.Encode([]rune(line))
+		switch {
+		case point < 0:
+		case point > len(units):
+			line += strings.Repeat(" ", point-len(units))
+		default:
+			line = string(utf16.Decode(units[:point]))
+		}
+
+		// powershell replaces the whole word, like fish
+		prefix := assignPrefix(os.Getenv("COMP_WORD"))
+		cands := candidates(c, c.Path[0], line, len(line), completion.PowerShell)
+		for i := range cands {
+			if cands[i].group == groupValue {
+				cands[i].value = prefix + cands[i].value
+			}
+		}
+		return writeCandidates(c.Out, cands)
+	},
+}
+
+var powershellScript = template.Must(template.New("powershell").Parse(`Register-ArgumentCompleter -Native -CommandName '{{.Name}}' -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$env:COMP_LINE = $commandAst.ToString()
+	$env:COMP_POINT = $cursorPosition - $commandAst.Extent.StartOffset
+	$env:COMP_WORD = $wordToComplete
+	$env:COMP_TYPE = 9
+	$out = & '{{.Name}}' {{.Args}} 2>$null
+	Remove-Item Env:COMP_LINE, Env:COMP_POINT, Env:COMP_WORD, Env:COMP_TYPE -ErrorAction SilentlyContinue
+	foreach ($l in $out) {
+		$group, $value, $desc = $l -split "` + "`" + `t", 3
+		$type = switch ($group) {
+			'option' { 'ParameterName' }
+			'command' { 'Command' }
+			default { 'ParameterValue' }
+		}
+		if (-not $desc) { $desc = $value }
+		[System.Management.Automation.CompletionResult]::new($value, $value, $type, $desc)
+	}
+}
+`))
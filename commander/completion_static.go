@@ -0,0 +1,268 @@
+package commander
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/completion"
+	"github.com/patroclos/go-conq/tree"
+)
+
+var optStatic = conq.Opt[bool]{
+	Name:        "static",
+	Description: "write a self-contained script, only calling back into the binary for dynamic predictions",
+}
+
+// WriteStaticBash writes a bash completion script for the tree at root, which
+// completes subcommands, getopt-style option names and the choices of static
+// predictors (see completion.Choices) without running the binary.  For dynamic
+// predictors it calls back into the completion command, which callback is
+// the command line of, like "app completion".
+func WriteStaticBash(w io.Writer, root *conq.Cmd, callback string) error {
+	if err := bashStatic.Execute(w, staticData(root, callback)); err != nil {
+		return fmt.Errorf("failed writing static bash completion script: %w", err)
+	}
+	return nil
+}
+
+// WriteStaticZsh is like WriteStaticBash for zsh, with callback being the
+// command line of the zsh completion command, like "app completion zsh".
+func WriteStaticZsh(w io.Writer, root *conq.Cmd, callback string) error {
+	if err := zshStatic.Execute(w, staticData(root, callback)); err != nil {
+		return fmt.Errorf("failed writing static zsh completion script: %w", err)
+	}
+	return nil
+}
+
+type staticScript struct {
+	Name     string
+	Func     string
+	Complete string
+	Cmds     []staticCmd
+}
+
+type staticCmd struct {
+	Path     string
+	Options  []candidate
+	Commands []candidate
+	// Values are the options taking a value.
+	Values []staticValue
+	// Args are the choices of the first positional argument, unless it's
+	// predictor is dynamic.
+	Args        []string
+	DynamicArgs bool
+}
+
+type staticValue struct {
+	Names   []string
+	Choices []string
+	Dynamic bool
+}
+
+func staticData(root *conq.Cmd, callback string) staticScript {
+	data := staticScript{
+		Name:     root.Name,
+		Func:     "_" + identifier(root.Name),
+		Complete: callback,
+	}
+	tree.Walk(root, func(pth conq.Pth) error {
+		cmd := pth[len(pth)-1]
+		if cmd.Help.Hidden {
+			return tree.SkipCmd
+		}
+		sc := staticCmd{Path: cmdline(pth)}
+		for _, opt := range cmd.Opts {
+			o := opt.Opt()
+			var names []string
+			for _, name := range strings.Split(o.Name, ",") {
+				flag := "--" + name
+				if len(name) == 1 {
+					flag = "-" + name
+				}
+				names = append(names, flag)
+				sc.Options = append(sc.Options, candidate{flag, o.Description, groupOption})
+			}
+			if o.Type != nil && o.Type.Kind() == reflect.Bool {
+				continue
+			}
+			v := staticValue{Names: names}
+			if o.Predict != nil {
+				v.Choices, v.Dynamic = completion.Choices(o.Predict)
+				v.Dynamic = !v.Dynamic
+			}
+			sc.Values = append(sc.Values, v)
+		}
+		for _, sub := range cmd.Commands {
+			if !sub.Help.Hidden {
+				sc.Commands = append(sc.Commands, candidate{sub.Name, sub.Help.Summary, groupCommand})
+			}
+		}
+		if len(cmd.Args) > 0 && cmd.Args[0].Opt().Predict != nil {
+			var ok bool
+			sc.Args, ok = completion.Choices(cmd.Args[0].Opt().Predict)
+			sc.DynamicArgs = !ok
+		}
+		data.Cmds = append(data.Cmds, sc)
+		return nil
+	})
+	return data
+}
+
+// shellQuote quotes s in single quotes, for bash and zsh alike.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var staticFuncs = template.FuncMap{
+	"quote": shellQuote,
+	// words quotes every word on it's own
+	"words": func(words []string) string {
+		quoted := make([]string, len(words))
+		for i, w := range words {
+			quoted[i] = shellQuote(w)
+		}
+		return strings.Join(quoted, " ")
+	},
+	// wordlist quotes the values of the candidates as one argument for
+	// compgen -W
+	"wordlist": func(cands []candidate, extra []string) string {
+		var words []string
+		for _, c := range cands {
+			words = append(words, c.value)
+		}
+		words = append(words, extra...)
+		return shellQuote(strings.Join(words, " "))
+	},
+	// describe quotes the candidates in the value:description format of
+	// zsh's _describe
+	"describe": func(cands []candidate) string {
+		quoted := make([]string, len(cands))
+		for i, c := range cands {
+			entry := strings.ReplaceAll(c.value, ":", `\:`)
+			if c.desc != "" {
+				entry += ":" + strings.ReplaceAll(c.desc, "\n", " ")
+			}
+			quoted[i] = shellQuote(entry)
+		}
+		return strings.Join(quoted, " ")
+	},
+	"join": strings.Join,
+	// paths is the case-pattern matching the paths of all subcommands
+	"paths": func(cmds []staticCmd) string {
+		var paths []string
+		for _, c := range cmds[1:] {
+			paths = append(paths, shellQuote(c.Path))
+		}
+		return strings.Join(paths, "|")
+	},
+}
+
+// bashStatic resolves the command path from the words before the cursor, like
+// Commander.ResolveCmd, and completes from the lists of the resolved command.
+// Subcommands are only offered, if all the words before the cursor are part of
+// the path.
+// Values of options without a predictor are left to bash's default completion.
+var bashStatic = template.Must(template.New("bash").Funcs(staticFuncs).Parse(`{{.Func}}_callback() {
+	COMPREPLY=($(COMP_LINE=$COMP_LINE COMP_POINT=$COMP_POINT COMP_TYPE=$COMP_TYPE {{.Complete}} 2>/dev/null))
+}
+
+{{.Func}}() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} pth={{quote .Name}} words= i
+	if [[ $cur == = ]]; then
+		cur=
+	elif [[ $prev == = ]]; then
+		prev=${COMP_WORDS[COMP_CWORD-2]}
+	fi
+{{- if gt (len .Cmds) 1}}
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "$pth ${COMP_WORDS[i]}" in
+		{{paths .Cmds}}) pth="$pth ${COMP_WORDS[i]}" ;;
+		*) break ;;
+		esac
+	done
+{{- end}}
+	case $pth in
+{{- range .Cmds}}
+	{{quote .Path}})
+{{- if .Values}}
+		case $prev in
+{{- range .Values}}
+		{{join .Names "|"}}) {{if .Dynamic}}{{$.Func}}_callback{{else if .Choices}}COMPREPLY=($(compgen -W {{quote (join .Choices " ")}} -- "$cur")){{else}}COMPREPLY=(){{end}}; return ;;
+{{- end}}
+		esac
+{{- end}}
+{{- if .DynamicArgs}}
+		[[ $cur != -* ]] && { {{$.Func}}_callback; return; }
+{{- end}}
+		words={{wordlist .Options .Args}}
+{{- if .Commands}}
+		(( i == COMP_CWORD )) && words+=" "{{wordlist .Commands nil}}
+{{- end}}
+		;;
+{{- end}}
+	esac
+	COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+
+complete -o default -F {{.Func}} {{.Name}}
+`))
+
+// zshStatic works like bashStatic, with the callback being the function of the
+// dynamic zsh script.
+var zshStatic = template.Must(template.Must(zshScript.Clone()).New("zsh-static").Funcs(staticFuncs).Parse(`#compdef {{.Name}}
+
+{{.Func}}_callback() {
+{{- template "callback" .}}
+}
+
+{{.Func}}() {
+	local -a opts cmds vals
+	local prev=${words[CURRENT-1]} pth={{quote .Name}} i
+	if [[ $PREFIX == -*=* ]]; then
+		prev=${PREFIX%%=*}
+	fi
+{{- if gt (len .Cmds) 1}}
+	for ((i = 2; i < CURRENT; i++)); do
+		case "$pth ${words[i]}" in
+		{{paths .Cmds}}) pth="$pth ${words[i]}" ;;
+		*) break ;;
+		esac
+	done
+{{- end}}
+	case $pth in
+{{- range .Cmds}}
+	{{quote .Path}})
+{{- if .Values}}
+		case $prev in
+{{- range .Values}}
+		{{join .Names "|"}}) {{if .Dynamic}}{{$.Func}}_callback{{else}}[[ $PREFIX == -*=* ]] && compset -P '*='; {{if .Choices}}vals=({{words .Choices}}); compadd -a vals{{else}}_default{{end}}{{end}}; return ;;
+{{- end}}
+		esac
+{{- end}}
+{{- if .DynamicArgs}}
+		[[ $PREFIX != -* ]] && { {{$.Func}}_callback; return; }
+{{- end}}
+		opts=({{describe .Options}})
+{{- if .Commands}}
+		(( i == CURRENT )) && cmds=({{describe .Commands}})
+{{- end}}
+		vals=({{words .Args}})
+		;;
+{{- end}}
+	esac
+	(( ${#cmds} )) && _describe -t commands 'command' cmds
+	(( ${#opts} )) && _describe -t options 'option' opts
+	(( ${#vals} )) && compadd -a vals
+	return 0
+}
+
+if [[ $funcstack[1] == {{.Func}} ]]; then
+	{{.Func}} "$@"
+else
+	compdef {{.Func}} {{.Name}}
+fi
+`))
@@ -0,0 +1,73 @@
+package commander_test
+
+import (
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/commander/comptest"
+	"github.com/patroclos/go-conq/getopt"
+	"github.com/posener/complete"
+)
+
+func TestBashCompletionQuoting(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{conq.Opt[string]{Name: "dir", Predict: complete.PredictSet("my dir", "other")}},
+	}
+	cmdr := commander.New(getopt.New(), nil)
+
+	comptest.Check(t, cmdr, root, "app --dir m", "my\\ dir")
+	comptest.Check(t, cmdr, root, "app --dir \"my d", "my dir")
+	comptest.Check(t, cmdr, root, "app --dir my\\ d", "my\\ dir")
+	comptest.Check(t, cmdr, root, "app --dir 'o", "other")
+}
+
+func TestCompletionSlots(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{
+			conq.Opt[string]{Name: "mode,m", Predict: complete.PredictSet("fast", "slow")},
+			conq.Opt[bool]{Name: "verbose"},
+		},
+		Args: conq.Opts{
+			conq.Opt[string]{Name: "src", Predict: complete.PredictSet("a.txt")},
+			conq.Opt[string]{Name: "dst", Predict: complete.PredictSet("b.txt")},
+		},
+		Commands: []*conq.Cmd{{Name: "serve"}},
+	}
+	cmdr := commander.New(getopt.New(), nil)
+
+	comptest.Check(t, cmdr, root, "app ", "--mode", "-m", "--verbose", "serve", "a.txt")
+	comptest.Check(t, cmdr, root, "app -m ", "fast", "slow")
+	comptest.Check(t, cmdr, root, "app --mode ", "fast", "slow")
+	comptest.Check(t, cmdr, root, "app -m fast ", "--verbose", "a.txt")
+	comptest.Check(t, cmdr, root, "app --verbose a.txt ", "b.txt")
+	comptest.Check(t, cmdr, root, "app --verbose -- ", "a.txt")
+}
+
+// branches predicts the branches of the --repo given before.
+type branches struct{}
+
+func (branches) Predict(complete.Args) []string { return nil }
+
+func (branches) PredictCtx(c conq.Ctx, _ complete.Args) []string {
+	return []string{c.Strings["repo"] + "/main"}
+}
+
+func TestCompletionContextPredictor(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{
+			conq.Opt[string]{Name: "repo"},
+			conq.Opt[int]{Name: "depth"},
+			conq.Opt[string]{Name: "branch", Predict: branches{}},
+		},
+		Args: conq.Opts{conq.Opt[string]{Name: "ref", Predict: branches{}}},
+	}
+	cmdr := commander.New(getopt.New(), nil)
+
+	comptest.Check(t, cmdr, root, "app --repo conq --branch ", "conq/main")
+	comptest.Check(t, cmdr, root, "app --repo=conq --depth x --branch=", "conq/main")
+	comptest.Check(t, cmdr, root, "app --repo conq c", "conq/main")
+}
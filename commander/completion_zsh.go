@@ -0,0 +1,80 @@
+package commander
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/completion"
+)
+
+var cmdZsh *conq.Cmd = &conq.Cmd{
+	Name: "zsh",
+	Opts: conq.Opts{optStatic},
+	Help: conq.CmdHelp{Summary: "zsh completion", NoHelpFlag: true},
+	Run: func(c conq.Ctx) error {
+		line, point, _, ok := completionContext()
+		if ok {
+			return writeCandidates(c.Out, candidates(c, c.Path[0], line, point, completion.Bash))
+		}
+		if static, _ := optStatic.Get(c); static {
+			return WriteStaticZsh(c.Out, c.Path[0], cmdline(c.Path))
+		}
+		err := zshScript.Execute(c.Out, map[string]string{
+			"Name":     c.Path[0].Name,
+			"Func":     "_" + identifier(c.Path[0].Name),
+			"Complete": cmdline(c.Path),
+		})
+		if err != nil {
+			return fmt.Errorf("failed writing zsh completion script: %w", err)
+		}
+		return nil
+	},
+}
+
+// identifier replaces all characters of s, that aren't valid in shell function
+// names, with underscores.
+func identifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// zshScript calls back into the binary with the words up to the cursor in
+// COMP_LINE and sorts the candidates into options, commands and values.  For
+// --opt=value, only the value is completed.
+var zshScript = template.Must(template.New("zsh").Parse(`#compdef {{.Name}}
+
+{{.Func}}() {
+{{- template "callback" .}}
+}
+
+if [[ $funcstack[1] == {{.Func}} ]]; then
+	{{.Func}} "$@"
+else
+	compdef {{.Func}} {{.Name}}
+fi
+{{define "callback"}}
+	local -a opts cmds vals
+	local cl="${(j: :)words[1,CURRENT-1]} ${PREFIX}" out
+	if [[ $PREFIX == -*=* ]]; then
+		compset -P '*='
+	fi
+	for out in "${(@f)$(COMP_LINE="$cl" COMP_POINT=${#cl} COMP_TYPE=9 {{.Complete}} 2>/dev/null)}"; do
+		local -a part=("${(@ps:\t:)out}")
+		local value=${part[2]//:/\\:} desc=${part[3]}
+		case ${part[1]} in
+		option) opts+=("${value}${desc:+:$desc}") ;;
+		command) cmds+=("${value}${desc:+:$desc}") ;;
+		value) vals+=("${part[2]}") ;;
+		esac
+	done
+	(( ${#cmds} )) && _describe -t commands 'command' cmds
+	(( ${#opts} )) && _describe -t options 'option' opts
+	(( ${#vals} )) && compadd -a vals
+	return 0
+{{- end}}`))
@@ -0,0 +1,38 @@
+// Package comptest checks the shell completion of Cmd-trees in tests, without
+// running a shell.
+package comptest
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/commander"
+)
+
+// Check fails the test, unless completing line with the cursor at its end
+// offers exactly the candidates expect, in any order.  Like bash, a line ending
+// in a space completes a new word.
+func Check(t testing.TB, com conq.Commander, root *conq.Cmd, line string, expect ...string) {
+	t.Helper()
+	var out bytes.Buffer
+	c := conq.OSContext()
+	c.Out = &out
+	c.Com = com
+	if err := commander.Complete(c, root, line, len(line), commander.CompNormal); err != nil {
+		t.Fatalf("completing %q: %v", line, err)
+	}
+
+	var got []string
+	if out.Len() > 0 {
+		got = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	}
+	sort.Strings(got)
+	want := append([]string(nil), expect...)
+	sort.Strings(want)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("completing %q: expected %q, got %q", line, want, got)
+	}
+}
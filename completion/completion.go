@@ -1,13 +1,21 @@
 package completion
 
-import "github.com/posener/complete"
+import (
+	"reflect"
 
-type Context struct {
-	Args complete.Args
+	"github.com/posener/complete"
+)
 
-	// is any of this used?
-	Values  map[string]any
-	Strings map[string]string
-	Options []string
-	Closed  bool
+// Choices returns the candidates of a static predictor, like the ones created
+// by complete.PredictSet.  It reports false for any predictor whose candidates
+// may depend on the arguments or environment.
+func Choices(p complete.Predictor) ([]string, bool) {
+	if p == nil {
+		return nil, false
+	}
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.String {
+		return nil, false
+	}
+	return p.Predict(complete.Args{}), true
 }
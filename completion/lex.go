@@ -0,0 +1,165 @@
+package completion
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Syntax is a shell's quoting rules, which command lines are split into words
+// by.
+type Syntax int
+
+const (
+	// Bash are the quoting rules of bash and zsh, with backslash escapes.
+	Bash Syntax = iota
+	// Fish also escapes quotes and backslashes inside single quotes, but not
+	// backticks inside double quotes.
+	Fish
+	// PowerShell escapes with backticks instead of backslashes, which are
+	// ordinary characters, and a quote inside quotes of the same kind by
+	// doubling it.
+	PowerShell
+)
+
+// Line is a command line being completed, split into words.
+type Line struct {
+	// Words before the one under the cursor, unquoted.
+	Words []string
+	// Word is the unquoted word under the cursor and Prefix the part of it
+	// before the cursor.
+	Word, Prefix string
+	// Quote is the quote open at the cursor, '\'' or '"', or 0 outside of
+	// quotes.
+	Quote rune
+}
+
+// Lex splits line into words by the quoting rules of bash, with the cursor at
+// the byte offset point.  An offset out of range puts the cursor at the end of
+// line.
+func Lex(line string, point int) Line {
+	return Bash.Lex(line, point)
+}
+
+// Lex is like the Lex function, splitting line by the quoting rules of s.
+func (s Syntax) Lex(line string, point int) Line {
+	if point < 0 || point > len(line) {
+		point = len(line)
+	}
+
+	var (
+		l       Line
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+		// cursor is the index of the word under the cursor and onWord whether
+		// there is one, instead of whitespace
+		cursor  int
+		onWord  bool
+		reached bool
+		// closed is the quote closed by the previous character, which
+		// powershell reopens, if it's repeated
+		closed rune
+	)
+	escape, escapable := '\\', "\"\\$`\n"
+	switch s {
+	case Fish:
+		escapable = "\"\\$\n"
+	case PowerShell:
+		escape, escapable = '`', ""
+	}
+	end := func() {
+		if inWord {
+			words = append(words, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+	atCursor := func(next rune) {
+		reached = true
+		cursor = len(words)
+		l.Prefix = word.String()
+		l.Quote = quote
+		// the cursor in front of a word is on it
+		onWord = inWord || next != 0 && !unicode.IsSpace(next)
+	}
+
+	for i, r := range line {
+		if i >= point && !reached {
+			atCursor(r)
+		}
+		if s == PowerShell && closed != 0 && r == closed {
+			word.WriteRune(r)
+			quote, closed = r, 0
+			continue
+		}
+		closed = 0
+		switch {
+		case escaped:
+			escaped = false
+			if r != '\n' {
+				word.WriteRune(r)
+			}
+		case quote == '\'':
+			switch {
+			case r == '\'':
+				quote, closed = 0, r
+			case s == Fish && r == '\\' && i+1 < len(line) && strings.ContainsRune("'\\", rune(line[i+1])):
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote, closed = 0, r
+			case r == escape && (s == PowerShell || i+1 < len(line) && strings.ContainsRune(escapable, rune(line[i+1]))):
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == escape:
+			inWord, escaped = true, true
+		case r == '\'' || r == '"':
+			inWord, quote = true, r
+		case unicode.IsSpace(r):
+			end()
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	if !reached {
+		atCursor(0)
+	}
+	end()
+
+	l.Words = words[:cursor]
+	if onWord {
+		l.Word = words[cursor]
+	}
+	return l
+}
+
+// specials are the characters escaped by Line.Requote outside of quotes.
+const specials = " \t\n'\"\\$`&;|<>()*?[]!{}#~"
+
+// Requote quotes s for insertion in place of the word under the cursor,
+// continuing the quote open there.
+func (l Line) Requote(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case l.Quote == '\'' && r == '\'':
+			b.WriteString(`'\''`)
+			continue
+		case l.Quote == '"' && strings.ContainsRune("\"\\$`", r):
+			b.WriteRune('\\')
+		case l.Quote == 0 && strings.ContainsRune(specials, r):
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
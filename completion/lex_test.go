@@ -0,0 +1,83 @@
+package completion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		line   string
+		point  int
+		expect Line
+	}{
+		{"app --path \"my dir\" --", -1, Line{Words: []string{"app", "--path", "my dir"}, Word: "--", Prefix: "--"}},
+		{"app my\\ dir ", -1, Line{Words: []string{"app", "my dir"}}},
+		{"app 'it''s' x", -1, Line{Words: []string{"app", "its"}, Word: "x", Prefix: "x"}},
+		{"app --path=\"a b", -1, Line{Words: []string{"app"}, Word: "--path=a b", Prefix: "--path=a b", Quote: '"'}},
+		{"app 'a \\", -1, Line{Words: []string{"app"}, Word: "a \\", Prefix: "a \\", Quote: '\''}},
+		{"app \"\\$x\\y\"", -1, Line{Words: []string{"app"}, Word: "$x\\y", Prefix: "$x\\y"}},
+		{"app foobar baz", 7, Line{Words: []string{"app"}, Word: "foobar", Prefix: "foo"}},
+		{"app foo", 4, Line{Words: []string{"app"}, Word: "foo", Prefix: ""}},
+		{"app  foo", 4, Line{Words: []string{"app"}}},
+		{"", -1, Line{Words: []string{}}},
+	}
+	for _, tt := range tests {
+		got := Lex(tt.line, tt.point)
+		if len(got.Words) == 0 && len(tt.expect.Words) == 0 {
+			got.Words, tt.expect.Words = nil, nil
+		}
+		if !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%q at %d: expected %+v, got %+v", tt.line, tt.point, tt.expect, got)
+		}
+	}
+}
+
+func TestLexFish(t *testing.T) {
+	tests := []struct {
+		line   string
+		expect Line
+	}{
+		{`app 'it\'s' x`, Line{Words: []string{"app", "it's"}, Word: "x", Prefix: "x"}},
+		{"app 'a\\\\b\\c' \"\\`x\\$y\"", Line{Words: []string{"app", `a\b\c`}, Word: "\\`x$y", Prefix: "\\`x$y"}},
+	}
+	for _, tt := range tests {
+		if got := Fish.Lex(tt.line, -1); !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%q: expected %+v, got %+v", tt.line, tt.expect, got)
+		}
+	}
+}
+
+func TestLexPowerShell(t *testing.T) {
+	tests := []struct {
+		line   string
+		expect Line
+	}{
+		{`app --path C:\Users\me`, Line{Words: []string{"app", "--path"}, Word: `C:\Users\me`, Prefix: `C:\Users\me`}},
+		{"app my` dir ", Line{Words: []string{"app", "my dir"}}},
+		{"app 'it''s' x", Line{Words: []string{"app", "it's"}, Word: "x", Prefix: "x"}},
+		{"app \"say \"\"hi`\"\" 'C:\\", Line{Words: []string{"app", `say "hi"`}, Word: `C:\`, Prefix: `C:\`, Quote: '\''}},
+	}
+	for _, tt := range tests {
+		if got := PowerShell.Lex(tt.line, -1); !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%q: expected %+v, got %+v", tt.line, tt.expect, got)
+		}
+	}
+}
+
+func TestRequote(t *testing.T) {
+	tests := []struct {
+		quote         rune
+		value, expect string
+	}{
+		{0, "my dir", "my\\ dir"},
+		{0, "a=b", "a=b"},
+		{'"', "say \"$hi\"", "say \\\"\\$hi\\\""},
+		{'\'', "it's", "it'\\''s"},
+	}
+	for _, tt := range tests {
+		if got := (Line{Quote: tt.quote}).Requote(tt.value); got != tt.expect {
+			t.Errorf("%q in %q: expected %q, got %q", tt.value, tt.quote, tt.expect, got)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package completion
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+// files the predictors read, variables so tests can replace them.
+var (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+	hostsFile  = "/etc/hosts"
+)
+
+// Files predicts files with one of the extensions exts, like ".go", or any
+// file without exts.  Directories are predicted with a trailing slash, so the
+// completion can descend into them.
+func Files(exts ...string) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		return entries(a.Last, func(name string, dir bool) bool {
+			if dir || len(exts) == 0 {
+				return true
+			}
+			for _, ext := range exts {
+				if strings.HasSuffix(name, ext) {
+					return true
+				}
+			}
+			return false
+		})
+	})
+}
+
+// Dirs predicts directories only.
+func Dirs() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		return entries(a.Last, func(_ string, dir bool) bool { return dir })
+	})
+}
+
+// entries lists the directory last is in, keeping the entries accepted by
+// keep.  Hidden entries are only listed, if the name typed so far starts with
+// a dot.
+func entries(last string, keep func(name string, dir bool) bool) (names []string) {
+	dir := last[:strings.LastIndex(last, "/")+1]
+	base := last[len(dir):]
+	list := dir
+	if list == "" {
+		list = "."
+	}
+	ents, err := os.ReadDir(list)
+	if err != nil {
+		return nil
+	}
+	for _, e := range ents {
+		if strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(list, e.Name())); err == nil {
+				isDir = fi.IsDir()
+			}
+		}
+		if !keep(e.Name(), isDir) {
+			continue
+		}
+		name := dir + e.Name()
+		if isDir {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	return
+}
+
+// EnvVars predicts the names of the environment variables that are set.
+func EnvVars() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) []string {
+		var names []string
+		for _, kv := range os.Environ() {
+			if i := strings.Index(kv, "="); i > 0 {
+				names = append(names, kv[:i])
+			}
+		}
+		sort.Strings(names)
+		return names
+	})
+}
+
+// Users predicts the names of the local users in /etc/passwd.
+func Users() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) []string {
+		return firstFields(passwdFile)
+	})
+}
+
+// Groups predicts the names of the local groups in /etc/group.
+func Groups() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) []string {
+		return firstFields(groupFile)
+	})
+}
+
+// firstFields returns the first colon-separated field of every line of the
+// file at path, skipping comments.
+func firstFields(path string) (names []string) {
+	lines(path, func(l string) {
+		if name := strings.SplitN(l, ":", 2)[0]; name != "" {
+			names = append(names, name)
+		}
+	})
+	return
+}
+
+// Hosts predicts the hosts configured in ~/.ssh/config, leaving out
+// patterns, and the names in /etc/hosts.
+func Hosts() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) []string {
+		seen := make(map[string]bool)
+		var hosts []string
+		add := func(names ...string) {
+			for _, n := range names {
+				if !seen[n] && !strings.ContainsAny(n, "*?!") {
+					seen[n] = true
+					hosts = append(hosts, n)
+				}
+			}
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			lines(filepath.Join(home, ".ssh", "config"), func(l string) {
+				fields := strings.Fields(l)
+				if len(fields) > 1 && strings.EqualFold(fields[0], "host") {
+					add(fields[1:]...)
+				}
+			})
+		}
+		lines(hostsFile, func(l string) {
+			if fields := strings.Fields(l); len(fields) > 1 {
+				add(fields[1:]...)
+			}
+		})
+		sort.Strings(hosts)
+		return hosts
+	})
+}
+
+// lines calls fn with every line of the file at path, that isn't empty or a
+// comment.  Missing files have no lines.
+func lines(path string, fn func(string)) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		l := sc.Text()
+		if i := strings.Index(l, "#"); i != -1 {
+			l = l[:i]
+		}
+		if l = strings.TrimSpace(l); l != "" {
+			fn(l)
+		}
+	}
+}
+
+// Interfaces predicts the names of the network interfaces.
+func Interfaces() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) (names []string) {
+		ifaces, _ := net.Interfaces()
+		for _, iface := range ifaces {
+			names = append(names, iface.Name)
+		}
+		return
+	})
+}
+
+// Addrs predicts the IP addresses of the network interfaces.
+func Addrs() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) (ips []string) {
+		addrs, _ := net.InterfaceAddrs()
+		for _, addr := range addrs {
+			if n, ok := addr.(*net.IPNet); ok {
+				ips = append(ips, n.IP.String())
+			}
+		}
+		return
+	})
+}
+
+// HardwareAddrs predicts the hardware addresses of the network interfaces.
+func HardwareAddrs() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) (macs []string) {
+		ifaces, _ := net.Interfaces()
+		for _, iface := range ifaces {
+			if len(iface.HardwareAddr) > 0 {
+				macs = append(macs, iface.HardwareAddr.String())
+			}
+		}
+		return
+	})
+}
+
+// GitRefs predicts the branches and tags of the git repository in the working
+// directory.
+func GitRefs() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) []string {
+		out, err := exec.Command("git", "for-each-ref", "--format=%(refname:short)", "refs/heads", "refs/tags").Output()
+		if err != nil {
+			return nil
+		}
+		return strings.Fields(string(out))
+	})
+}
+
+var (
+	typeIP           = reflect.TypeOf(net.IP{})
+	typeHardwareAddr = reflect.TypeOf(net.HardwareAddr{})
+)
+
+// ForType returns the predictor for values of type t: the interface addresses
+// for net.IP and the interface hardware addresses for net.HardwareAddr.  For
+// other types, including bool, there is none.
+func ForType(t reflect.Type) complete.Predictor {
+	switch t {
+	case typeIP:
+		return Addrs()
+	case typeHardwareAddr:
+		return HardwareAddrs()
+	}
+	return nil
+}
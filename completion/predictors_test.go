@@ -0,0 +1,85 @@
+package completion
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/posener/complete"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.txt", ".hidden.go", "sub/c.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		p      complete.Predictor
+		last   string
+		expect []string
+	}{
+		{Files(".go"), dir + "/", []string{dir + "/a.go", dir + "/sub/"}},
+		{Files(), dir + "/", []string{dir + "/a.go", dir + "/b.txt", dir + "/sub/"}},
+		{Files(".go"), dir + "/.", []string{dir + "/.hidden.go", dir + "/a.go", dir + "/sub/"}},
+		{Dirs(), dir + "/", []string{dir + "/sub/"}},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Predict(complete.Args{Last: tt.last}); !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%q: expected %q, got %q", tt.last, tt.expect, got)
+		}
+	}
+}
+
+func TestFilePredictors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	defer func(p, g, h string) { passwdFile, groupFile, hostsFile = p, g, h }(passwdFile, groupFile, hostsFile)
+	passwdFile = write("passwd", "root:x:0:0::/root:/bin/sh\n# comment\nalice:x:1000:1000::/home/alice:/bin/sh\n")
+	groupFile = write("group", "wheel:x:10:alice\n")
+	hostsFile = write("hosts", "127.0.0.1 localhost # loopback\n10.0.0.2 db db.internal\n")
+	if err := os.Mkdir(filepath.Join(dir, ".ssh"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write(".ssh/config", "Host build *.example.com\n  HostName 10.0.0.3\nhost db\n")
+
+	tests := []struct {
+		p      complete.Predictor
+		expect string
+	}{
+		{Users(), "root alice"},
+		{Groups(), "wheel"},
+		{Hosts(), "build db db.internal localhost"},
+	}
+	// the home directory is looked up when predicting
+	t.Setenv("HOME", dir)
+	for _, tt := range tests {
+		if got := strings.Join(tt.p.Predict(complete.Args{}), " "); got != tt.expect {
+			t.Errorf("expected %q, got %q", tt.expect, got)
+		}
+	}
+}
+
+func TestForType(t *testing.T) {
+	if ForType(reflect.TypeOf(net.IP{})) == nil {
+		t.Error("expected a predictor for net.IP")
+	}
+	if p := ForType(reflect.TypeOf(true)); p != nil {
+		t.Errorf("expected no predictor for bool, got %v", p)
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/patroclos/go-conq"
 	"github.com/patroclos/go-conq/aid"
 	"github.com/patroclos/go-conq/aid/cmdhelp"
+	"github.com/patroclos/go-conq/aid/man"
+	"github.com/patroclos/go-conq/aid/schema"
+	"github.com/patroclos/go-conq/aid/version"
 	"github.com/patroclos/go-conq/commander"
 	_ "github.com/patroclos/go-conq/example/internal/translations"
 	"github.com/patroclos/go-conq/example/unansi"
@@ -50,19 +53,20 @@ var envDebug = conq.Opt[string]{Name: "CONQ_DEBUG"}
 
 func New() *conq.Cmd {
 	helpCmd := cmdhelp.New(helpFs)
-	hCmd := cmdhelp.New(helpFs)
-	hCmd.Name = "-h"
 	return &conq.Cmd{
-		Name: "example",
-		Opts: []conq.Opter{optPath, optAddr, optCidr, optMime, optCert, optCfg, optPrime, optMac},
-		Env:  conq.Opts{envDebug},
+		Name:    "example",
+		Version: "0.1.0",
+		Opts:    []conq.Opter{optPath, optAddr, optCidr, optMime, optCert, optCfg, optPrime, optMac},
+		Env:     conq.Opts{envDebug},
 		Commands: []*conq.Cmd{
 			helpCmd,
-			hCmd,
 			commander.CmdCompletion,
 			{Name: "foo", Commands: []*conq.Cmd{{Name: "baz"}}},
 			{Name: "bar"},
 			unansi.New(),
+			man.CmdGenMan,
+			schema.CmdSchema,
+			version.CmdVersion,
 		},
 		Run: run,
 	}
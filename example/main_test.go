@@ -8,7 +8,9 @@ import (
 	"github.com/patroclos/go-conq"
 	"github.com/patroclos/go-conq/aid"
 	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/commander/comptest"
 	"github.com/patroclos/go-conq/getopt"
+	"github.com/patroclos/go-conq/lint/linttest"
 )
 
 func TestUnansiCommand(t *testing.T) {
@@ -30,3 +32,16 @@ func TestUnansiCommand(t *testing.T) {
 		t.Errorf("expected colorful, got %q", output)
 	}
 }
+
+func TestLint(t *testing.T) {
+	linttest.Check(t, New())
+}
+
+func TestCompletion(t *testing.T) {
+	root := New()
+	com := commander.New(getopt.New(), aid.DefaultHelp)
+
+	comptest.Check(t, com, root, "example --path ", "good", "bad", "ugly")
+	comptest.Check(t, com, root, "example foo ", "baz")
+	comptest.Check(t, com, root, "example help fo", "foo")
+}
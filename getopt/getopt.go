@@ -6,7 +6,7 @@ import (
 	"strings"
 
 	"github.com/patroclos/go-conq"
-	"github.com/patroclos/go-conq/completion"
+	"github.com/posener/complete"
 )
 
 func New() conq.Optioner {
@@ -15,28 +15,84 @@ func New() conq.Optioner {
 
 type getopt struct{}
 
-func (*getopt) CompleteOptions(ctx completion.Context, opts ...conq.Opter) []string {
-	a := ctx.Args
+// CompleteOptions completes the value of an option, if the last completed
+// argument is an option expecting one, or otherwise the names of the options
+// that haven't been given yet.  Like ExtractOptions, it stops at the first
+// positional argument or "--".
+//
+// The options given in the completed arguments are extracted into the Values
+// and Strings of the returned Ctx, which is also passed to the predictor of the
+// value.  Unlike ExtractOptions, unknown options and values failing to parse
+// are skipped.
+func (*getopt) CompleteOptions(ctx conq.Ctx, a complete.Args, opts ...conq.Opter) (conq.Ctx, []string) {
+	ctx.Values = make(map[string]any, len(opts))
+	ctx.Strings = make(map[string]string, len(opts))
+	set := func(o conq.O, val string) {
+		ctx.Strings[o.Name] = val
+		switch {
+		case o.Parse == nil:
+			ctx.Values[o.Name] = val
+		default:
+			if v, err := o.Parse(val); err == nil {
+				ctx.Values[o.Name] = v
+			}
+		}
+	}
+
+	used := make(map[string]bool, len(opts))
+	var target *conq.O
+	for _, arg := range a.Completed {
+		if target != nil {
+			set(*target, arg)
+			target = nil
+			continue
+		}
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return ctx, nil
+		}
+		name := strings.TrimLeft(arg, "-")
+		idx := strings.Index(name, "=")
+		if idx != -1 {
+			name = name[:idx]
+		}
+		for _, opt := range opts {
+			o := opt.Opt()
+			for _, n := range strings.Split(o.Name, ",") {
+				if n != name {
+					continue
+				}
+				used[o.Name] = true
+				switch {
+				case idx != -1:
+					set(o, strings.TrimLeft(arg, "-")[idx+1:])
+				case o.Type != nil && o.Type.Kind() == reflect.Bool:
+					ctx.Strings[o.Name] = ""
+					ctx.Values[o.Name] = true
+				default:
+					target = &o
+				}
+			}
+		}
+	}
+	if target != nil {
+		return ctx, conq.Predict(target.Predict, ctx, a)
+	}
+
 	names := make([]string, 0, len(opts))
 	for _, opt := range opts {
 		o := opt.Opt()
-
+		if used[o.Name] {
+			continue
+		}
 		for _, name := range strings.Split(o.Name, ",") {
 			if len(name) == 1 {
 				names = append(names, fmt.Sprintf("-%s", name))
 				continue
 			}
 			names = append(names, fmt.Sprintf("--%s", name))
-
-			lastCompIsFlag := strings.HasPrefix(a.LastCompleted, "--")
-			wasAssign := strings.Contains(a.LastCompleted, "=")
-			if o.Predict != nil && lastCompIsFlag && !wasAssign {
-				// complete using o.Predict?
-				names = append(names, o.Predict.Predict(a)...)
-			}
 		}
 	}
-	return names
+	return ctx, names
 }
 
 func (*getopt) ExtractOptions(ctx conq.Ctx, opts ...conq.Opter) (conq.Ctx, error) {
@@ -105,7 +161,7 @@ a:
 					}
 
 					if len(ctx.Args) == 1 {
-						return ctx, fmt.Errorf("missing value for option %q", o.Name)
+						return ctx, fmt.Errorf("missing value for option %q: %w", o.Name, conq.ErrMissingValue)
 					}
 
 					targetOpt = &o
@@ -192,7 +248,7 @@ a:
 	}
 
 	if targetOpt != nil {
-		return ctx, fmt.Errorf("expecting value for option %s", targetOpt.Name)
+		return ctx, fmt.Errorf("expecting value for option %s: %w", targetOpt.Name, conq.ErrMissingValue)
 	}
 
 	return ctx, nil
@@ -2,12 +2,14 @@ package getopt_test
 
 import (
 	"net"
+	"strings"
 	"testing"
 
 	"github.com/patroclos/go-conq"
 	"github.com/patroclos/go-conq/aid"
 	"github.com/patroclos/go-conq/commander"
 	"github.com/patroclos/go-conq/getopt"
+	"github.com/posener/complete"
 )
 
 // TODO: test cases (flag without value, short option, generic modifiers, aliases, assignment-style)
@@ -154,3 +156,51 @@ func TestCustomParser(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestCompleteOptions(t *testing.T) {
+	opts := conq.Opts{
+		conq.Opt[string]{Name: "mode,m", Predict: complete.PredictSet("fast", "slow")},
+		conq.Opt[bool]{Name: "verbose,v"},
+		conq.Opt[string]{Name: "out"},
+	}
+	tests := []struct {
+		completed []string
+		expect    []string
+	}{
+		{nil, []string{"--mode", "-m", "--verbose", "-v", "--out"}},
+		{[]string{"--mode"}, []string{"fast", "slow"}},
+		{[]string{"-m"}, []string{"fast", "slow"}},
+		{[]string{"--out"}, nil},
+		{[]string{"-v", "--mode=fast"}, []string{"--out"}},
+		{[]string{"-m", "fast", "--verbose"}, []string{"--out"}},
+		{[]string{"file"}, nil},
+		{[]string{"--"}, nil},
+	}
+	for _, tt := range tests {
+		_, got := getopt.New().CompleteOptions(conq.Ctx{}, complete.Args{Completed: tt.completed}, opts...)
+		if strings.Join(got, " ") != strings.Join(tt.expect, " ") {
+			t.Errorf("%q: expected %q, got %q", tt.completed, tt.expect, got)
+		}
+	}
+}
+
+func TestCompleteOptionsValues(t *testing.T) {
+	opts := conq.Opts{
+		conq.Opt[int]{Name: "depth,d"},
+		conq.Opt[bool]{Name: "verbose"},
+		conq.Opt[string]{Name: "repo"},
+	}
+	ctx := conq.Ctx{Values: map[string]any{}, Strings: map[string]string{}}
+	a := complete.Args{Completed: []string{"--unknown", "-d", "x", "--verbose", "--repo=conq"}}
+	cc, _ := getopt.New().CompleteOptions(ctx, a, opts...)
+
+	if len(ctx.Values) != 0 || len(ctx.Strings) != 0 {
+		t.Errorf("expected the given maps untouched, got %v and %v", ctx.Values, ctx.Strings)
+	}
+	if cc.Strings["depth,d"] != "x" || cc.Values["depth,d"] != nil {
+		t.Errorf("expected unparsable depth only in Strings, got %q and %v", cc.Strings["depth,d"], cc.Values["depth,d"])
+	}
+	if cc.Values["verbose"] != true || cc.Values["repo"] != "conq" {
+		t.Errorf("unexpected values %v", cc.Values)
+	}
+}
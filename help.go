@@ -16,16 +16,29 @@ type Helper interface {
 
 // HelpSelector is a func that is used when walking the command-tree to assemble
 // only the subjects the selector accepts into the helptext.
+// It is called with the command help was requested for, the subject that is
+// being considered, the Helper in use and the subject's identifier (see
+// aid.SubjectIdentifier).  The subject's helptext is included if accept is
+// true and it's subcommands are considered if recurse is true.
 type HelpSelector func(*Cmd, HelpSubject, Helper, string) (accept, recurse bool)
 
-// GO AWAY!
-
 // CmdHelp details how this command is to be treated by help/assistance commands
 type CmdHelp struct {
+	// Summary is a one-line description of the command, used in listings and
+	// generated documentation.
+	Summary string
+	// Hidden commands are omitted from help listings, generated documentation
+	// and completion, but can still be invoked.
+	Hidden bool
+	// NoHelpFlag disables the -h and --help flags the commander recognizes on
+	// this command.
+	NoHelpFlag bool
 	// Filter for the subjects that will considered in help-generation.
 	// An interesting usecase could be to skip non-runnable commands or select for
 	// a certain depth in the tree.
 	Select HelpSelector
 	// Articles contains templates for generating helptexts using cmdhelp.HelpContext.
+	// They are laid out like the help-directory given to cmdhelp.New and merged
+	// with it.
 	Articles fs.FS
 }
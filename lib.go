@@ -1,6 +1,7 @@
 package conq
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
@@ -8,6 +9,7 @@ import (
 	"github.com/alexflint/go-scalar"
 	"github.com/patroclos/go-conq/completion"
 	"github.com/posener/complete"
+	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
 
@@ -20,10 +22,23 @@ type Cmd struct {
 	Args     Opts
 	Env      Opts
 	Version  string
+	Hooks    Hooks
+	Help     CmdHelp
 }
 
 type Pth []*Cmd
 
+// Version is the Version of the command nearest to the end of the path that
+// declares one.
+func (p Pth) Version() string {
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i].Version != "" {
+			return p[i].Version
+		}
+	}
+	return ""
+}
+
 // Ctx is the context in which a command (Cmd) runs.  It contains the std-streams,
 // arguments (options extracted before calling Cmd.Run), option-values, the path
 // within the command-tree this invocation is located in and a locale-aware
@@ -35,8 +50,41 @@ type Ctx struct {
 	Values   map[string]any
 	Strings  map[string]string
 	Printer  *message.Printer
-	Path     Pth
-	Com      Commander
+	// Langs are the preferred languages of the user, most preferred first.
+	// Unlike the language of Printer, they aren't matched to the message
+	// catalog.
+	Langs []language.Tag
+	Path  Pth
+	Com   Commander
+}
+
+// Hook is called by the commander at a defined point of an invocation.  The
+// returned Ctx is passed on to following hooks and eventually to Cmd.Run, so
+// hooks can enrich Values or rewrite Args.
+type Hook func(Ctx) (Ctx, error)
+
+// Middleware wraps the invocation of a Cmd.Run.
+type Middleware func(next func(Ctx) error) func(Ctx) error
+
+// Hooks are the points at which a Cmd can intervene in the invocation of itself
+// or any of it's subcommands.  They are inherited down Ctx.Path: hooks of the
+// root run for every invocation, hooks of a subcommand only for invocations
+// within it's subtree.
+type Hooks struct {
+	// PreExtract is run before options are extracted from Ctx.Args.
+	// Values set here may be reset by the Optioner.
+	PreExtract Hook
+	// PreRun is run after options, environment and positional arguments have
+	// been extracted and validated.
+	PreRun Hook
+	// Wrap wraps the Run of the invoked command.  Wrappers of commands closer to
+	// the root are run first.
+	Wrap Middleware
+	// OnError is called with any error the invocation failed with.  The returned
+	// error replaces the original one.  Handlers closer to the leaf are called
+	// first, once one returns nil the error is handled and no further handlers
+	// are called.
+	OnError func(Ctx, error) error
 }
 
 type Commander interface {
@@ -45,10 +93,35 @@ type Commander interface {
 	Optioner() Optioner
 }
 
+// ErrMissingValue is returned by Optioner.ExtractOptions, if the arguments end
+// in an option lacking it's value.
+var ErrMissingValue = errors.New("missing option value")
+
 // Optioner provides extraction and completion of CLI options.
 type Optioner interface {
 	ExtractOptions(Ctx, ...Opter) (Ctx, error)
-	CompleteOptions(completion.Context, ...Opter) []string
+	// CompleteOptions returns the candidates for a.Last and the Ctx with the
+	// options given in a.Completed extracted into fresh Values and Strings.
+	CompleteOptions(c Ctx, a complete.Args, opts ...Opter) (Ctx, []string)
+}
+
+// CtxPredictor can be implemented by the O.Predict of options and positional
+// arguments, that need the context of the invocation being completed.  Ctx.Path
+// is the path of the command they belong to and Ctx.Values and Ctx.Strings hold
+// the options given before the completed word.
+type CtxPredictor interface {
+	PredictCtx(Ctx, complete.Args) []string
+}
+
+// Predict returns the predictions of p for a, passing c to a CtxPredictor.
+func Predict(p complete.Predictor, c Ctx, a complete.Args) []string {
+	switch p := p.(type) {
+	case nil:
+		return nil
+	case CtxPredictor:
+		return p.PredictCtx(c, a)
+	}
+	return p.Predict(a)
 }
 
 // This interface exists to facilitate the Opt[T] and ReqOpt[T] types with filter effects
@@ -68,6 +141,11 @@ type O struct {
 	Type reflect.Type
 	// shell-completion
 	Predict complete.Predictor
+	// a short description used in helptexts and generated documentation
+	Description string
+	// the value used if the parameter isn't given, in the form it's parsed
+	// from by Parse
+	Default string
 }
 
 func (o O) WithName(name string) O {
@@ -76,7 +154,8 @@ func (o O) WithName(name string) O {
 }
 
 // Opt[T any] wraps a base-option (usually only containing a name) in an Opter
-// interface, which will apply defaults to O.Parse and O.Type values.
+// interface, which will apply defaults to O.Parse, O.Type and O.Predict values
+// (see completion.ForType).
 // The default O.Parse implementation will use the github.com/alexflint/go-scalar
 // package to parse a (value T) from a string.  The default implementations
 // supports the encoding.TextUnmarshaler interface.
@@ -123,6 +202,9 @@ func (o Opt[T]) Opt() O {
 			return val, err
 		}
 	}
+	if o.Predict == nil {
+		o.Predict = completion.ForType(typ)
+	}
 	o.Type = typ
 	return O(o)
 }
@@ -0,0 +1,127 @@
+// Package lint detects malformed declarations in Cmd-trees, which would
+// otherwise only surface when the affected command is invoked.
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/alexflint/go-scalar"
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/aid"
+	"github.com/patroclos/go-conq/tree"
+)
+
+// Problem is a single issue found in a Cmd-tree.
+type Problem struct {
+	// Subject is the aid.SubjectIdentifier of the affected command or option.
+	Subject string
+	Msg     string
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s: %s", p.Subject, p.Msg)
+}
+
+// Validate checks every command in the tree rooted at root and reports all
+// problems found.
+func Validate(root *conq.Cmd) []Problem {
+	var problems []Problem
+	tree.Walk(root, func(pth conq.Pth) error {
+		problems = append(problems, validateCmd(pth)...)
+		return nil
+	})
+	return problems
+}
+
+func validateCmd(pth conq.Pth) (problems []Problem) {
+	cmd := pth[len(pth)-1]
+	report := func(opt conq.Opter, format string, args ...any) {
+		problems = append(problems, Problem{
+			Subject: aid.SubjectIdentifier(pth, opt),
+			Msg:     fmt.Sprintf(format, args...),
+		})
+	}
+
+	if cmd.Name == "" && len(pth) > 1 {
+		report(nil, "subcommand without name")
+	}
+
+	seen := make(map[string]bool, len(cmd.Commands))
+	for _, sub := range cmd.Commands {
+		if seen[sub.Name] {
+			report(nil, "duplicate subcommand %q", sub.Name)
+		}
+		seen[sub.Name] = true
+	}
+
+	names := make(map[string]string)
+	for _, opt := range cmd.Opts {
+		o := opt.Opt()
+		for _, name := range strings.Split(o.Name, ",") {
+			if name == "" {
+				report(opt, "empty option name")
+				continue
+			}
+			if other, ok := names[name]; ok {
+				report(opt, "name %q is already used by option %q", name, other)
+				continue
+			}
+			names[name] = o.Name
+		}
+	}
+
+	var optional string
+	for _, arg := range cmd.Args {
+		o := arg.Opt()
+		if !o.Require {
+			optional = o.Name
+			continue
+		}
+		if optional != "" {
+			report(arg, "required positional argument follows optional argument %q", optional)
+		}
+	}
+
+	if len(cmd.Args) > 0 && len(cmd.Commands) > 0 {
+		report(cmd.Args[0], "positional argument values matching a subcommand name resolve to the subcommand instead")
+	}
+
+	for _, opts := range []conq.Opts{cmd.Opts, cmd.Args, cmd.Env} {
+		for _, opt := range opts {
+			o := opt.Opt()
+			if o.Default != "" && o.Parse != nil {
+				if _, err := o.Parse(o.Default); err != nil {
+					report(opt, "invalid default %q: %v", o.Default, err)
+				}
+			}
+			if hasParse(opt) || o.Type == nil || scalar.CanParse(o.Type) {
+				continue
+			}
+			report(opt, "no Parse function for non-scalar type %v", o.Type)
+		}
+	}
+
+	return
+}
+
+// hasParse reports whether opt brings it's own O.Parse, as opposed to the
+// default injected by Opt[T] and ReqOpt[T], which only handles scalars.
+func hasParse(opt conq.Opter) bool {
+	v := reflect.ValueOf(opt)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return true
+	}
+	f := v.FieldByName("Parse")
+	if !f.IsValid() || f.Kind() != reflect.Func {
+		return true
+	}
+	return !f.IsNil()
+}
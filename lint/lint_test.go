@@ -0,0 +1,64 @@
+package lint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/lint"
+	"github.com/patroclos/go-conq/lint/linttest"
+)
+
+type nonScalar struct{ X, Y int }
+
+func TestValidate(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{
+			conq.Opt[string]{Name: "verbose,v"},
+			conq.Opt[bool]{Name: "version,v"},
+			conq.Opt[nonScalar]{Name: "point"},
+		},
+		Commands: []*conq.Cmd{
+			{Name: "foo"},
+			{Name: "foo"},
+			{
+				Name: "bar",
+				Opts: conq.Opts{conq.Opt[int]{Name: "depth", Default: "deep"}},
+				Args: conq.Opts{
+					conq.Opt[string]{Name: "first"},
+					conq.ReqOpt[string]{Name: "second"},
+				},
+				Commands: []*conq.Cmd{{Name: "baz"}},
+			},
+		},
+	}
+
+	expect := []string{
+		`app: duplicate subcommand "foo"`,
+		`app[version,v]: name "v" is already used by option "verbose,v"`,
+		`app[point]: no Parse function for non-scalar type lint_test.nonScalar`,
+		`app.bar[second]: required positional argument follows optional argument "first"`,
+		`app.bar[first]: positional argument values matching a subcommand name resolve to the subcommand instead`,
+		`app.bar[depth]: invalid default "deep": strconv.ParseInt: parsing "deep": invalid syntax`,
+	}
+
+	var got []string
+	for _, p := range lint.Validate(root) {
+		got = append(got, p.Error())
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("expected problems\n%s\ngot\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestCustomParseIsAccepted(t *testing.T) {
+	root := &conq.Cmd{
+		Name: "app",
+		Opts: conq.Opts{conq.Opt[nonScalar]{
+			Name:  "point",
+			Parse: func(string) (any, error) { return nonScalar{}, nil },
+		}},
+	}
+	linttest.Check(t, root)
+}
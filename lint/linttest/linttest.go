@@ -0,0 +1,17 @@
+// Package linttest checks Cmd-trees for the problems found by lint in tests.
+package linttest
+
+import (
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/lint"
+)
+
+// Check fails the test for every problem lint.Validate reports on the tree.
+func Check(t testing.TB, root *conq.Cmd) {
+	t.Helper()
+	for _, p := range lint.Validate(root) {
+		t.Error(p)
+	}
+}
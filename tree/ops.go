@@ -0,0 +1,106 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+)
+
+// AddCommands adds cmds to every command that doesn't already have a
+// subcommand of the same name, eg. to make help and completion available at
+// every level of the tree:
+//
+//	tree.Map(root, tree.AddCommands(cmdhelp.New(nil), commander.CmdCompletion))
+func AddCommands(cmds ...*conq.Cmd) MapFunc {
+	return func(_ conq.Pth, cmd *conq.Cmd) *conq.Cmd {
+		subs := cmd.Commands[:len(cmd.Commands):len(cmd.Commands)]
+	a:
+		for _, x := range cmds {
+			for _, sub := range cmd.Commands {
+				if sub.Name == x.Name {
+					continue a
+				}
+			}
+			subs = append(subs, x)
+		}
+		cmd.Commands = subs
+		return cmd
+	}
+}
+
+// Deprecate marks a command as deprecated, printing a warning including msg to
+// Ctx.Err whenever it is invoked.  Since hooks are inherited, it's sufficient to
+// mark the root of a deprecated subtree:
+//
+//	tree.Map(root, tree.At([]string{"old"}, tree.Deprecate("use new instead")))
+func Deprecate(msg string) MapFunc {
+	return func(pth conq.Pth, cmd *conq.Cmd) *conq.Cmd {
+		names := make([]string, len(pth))
+		for i, x := range pth {
+			names[i] = x.Name
+		}
+		name := strings.Join(names, " ")
+		cmd.Hooks.PreExtract = chainHooks(cmd.Hooks.PreExtract, func(c conq.Ctx) (conq.Ctx, error) {
+			fmt.Fprintf(c.Err, "warning: %q is deprecated: %s\n", name, msg)
+			return c, nil
+		})
+		return cmd
+	}
+}
+
+// PrefixEnv prepends prefix to the names of all environment variables of a
+// command.  The values are still accessible using the original options.
+func PrefixEnv(prefix string) MapFunc {
+	return func(_ conq.Pth, cmd *conq.Cmd) *conq.Cmd {
+		if len(cmd.Env) == 0 {
+			return cmd
+		}
+		env := make(conq.Opts, len(cmd.Env))
+		names := make([]string, len(cmd.Env))
+		for i, opt := range cmd.Env {
+			env[i] = prefixed{opt, prefix}
+			names[i] = opt.Opt().Name
+		}
+		cmd.Env = env
+		cmd.Hooks.PreRun = chainHooks(func(c conq.Ctx) (conq.Ctx, error) {
+			for _, name := range names {
+				if val, ok := c.Values[prefix+name]; ok {
+					c.Values[name] = val
+				}
+				if str, ok := c.Strings[prefix+name]; ok {
+					c.Strings[name] = str
+				}
+			}
+			return c, nil
+		}, cmd.Hooks.PreRun)
+		return cmd
+	}
+}
+
+type prefixed struct {
+	conq.Opter
+	prefix string
+}
+
+func (p prefixed) Opt() conq.O {
+	o := p.Opter.Opt()
+	o.Name = p.prefix + o.Name
+	return o
+}
+
+func chainHooks(first, then conq.Hook) conq.Hook {
+	switch {
+	case first == nil:
+		return then
+	case then == nil:
+		return first
+	}
+	return func(c conq.Ctx) (conq.Ctx, error) {
+		c, err := first(c)
+		if err != nil {
+			return c, err
+		}
+		return then(c)
+	}
+}
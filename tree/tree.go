@@ -0,0 +1,159 @@
+// Package tree provides operations on Cmd-trees.  None of the operations mutate
+// the tree they are given, transformations produce a new tree sharing all
+// untouched values with the original.
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/patroclos/go-conq"
+)
+
+// SkipCmd can be returned from a WalkFunc to skip the subcommands of the
+// current command.
+var SkipCmd = errors.New("skip this command")
+
+// WalkFunc is called for every command visited by Walk with the path from the
+// root to the visited command.
+type WalkFunc func(pth conq.Pth) error
+
+// Walk visits every command in the tree rooted at root in depth-first
+// pre-order.  Any error other than SkipCmd returned by fn stops the walk.
+func Walk(root *conq.Cmd, fn WalkFunc) error {
+	err := walk(conq.Pth{root}, fn)
+	if err == SkipCmd {
+		return nil
+	}
+	return err
+}
+
+func walk(pth conq.Pth, fn WalkFunc) error {
+	err := fn(pth)
+	if err == SkipCmd {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, sub := range pth[len(pth)-1].Commands {
+		if err := walk(append(pth[:len(pth):len(pth)], sub), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find resolves the command reached from root by following the subcommands
+// named by names.  It returns nil, if there is no such command.
+func Find(root *conq.Cmd, names ...string) conq.Pth {
+	pth := conq.Pth{root}
+a:
+	for _, name := range names {
+		for _, sub := range pth[len(pth)-1].Commands {
+			if sub.Name != name {
+				continue
+			}
+			pth = append(pth, sub)
+			continue a
+		}
+		return nil
+	}
+	return pth
+}
+
+// MapFunc transforms a command.  It receives the path to the command in the
+// original tree and a shallow copy of the command, which it may modify and
+// return.  Returning nil removes the command from the tree.
+type MapFunc func(pth conq.Pth, cmd *conq.Cmd) *conq.Cmd
+
+// Map returns a copy of the tree rooted at root with fn applied to every
+// command.  Subcommands are mapped before their parents, so commands added to
+// cmd.Commands by fn are not visited.
+func Map(root *conq.Cmd, fn MapFunc) *conq.Cmd {
+	return mapCmd(conq.Pth{root}, fn)
+}
+
+func mapCmd(pth conq.Pth, fn MapFunc) *conq.Cmd {
+	cmd := *pth[len(pth)-1]
+	if cmd.Commands != nil {
+		subs := make([]*conq.Cmd, 0, len(cmd.Commands))
+		for _, sub := range cmd.Commands {
+			if x := mapCmd(append(pth[:len(pth):len(pth)], sub), fn); x != nil {
+				subs = append(subs, x)
+			}
+		}
+		cmd.Commands = subs
+	}
+	return fn(pth, &cmd)
+}
+
+// Chain combines multiple MapFuncs into one, applying them in order.
+func Chain(fns ...MapFunc) MapFunc {
+	return func(pth conq.Pth, cmd *conq.Cmd) *conq.Cmd {
+		for _, fn := range fns {
+			if cmd == nil {
+				return nil
+			}
+			cmd = fn(pth, cmd)
+		}
+		return cmd
+	}
+}
+
+// At restricts fn to the command reached by following the subcommands named by
+// names from the root.
+func At(names []string, fn MapFunc) MapFunc {
+	return func(pth conq.Pth, cmd *conq.Cmd) *conq.Cmd {
+		if !pathIs(pth, names) {
+			return cmd
+		}
+		return fn(pth, cmd)
+	}
+}
+
+// Under restricts fn to the subtree of the command reached by following the
+// subcommands named by names from the root, including the command itself.
+func Under(names []string, fn MapFunc) MapFunc {
+	return func(pth conq.Pth, cmd *conq.Cmd) *conq.Cmd {
+		if len(pth)-1 < len(names) || !pathIs(pth[:len(names)+1], names) {
+			return cmd
+		}
+		return fn(pth, cmd)
+	}
+}
+
+func pathIs(pth conq.Pth, names []string) bool {
+	if len(pth)-1 != len(names) {
+		return false
+	}
+	for i, name := range names {
+		if pth[i+1].Name != name {
+			return false
+		}
+	}
+	return true
+}
+
+// Mount returns a copy of the tree with subs appended to the subcommands of the
+// command named by at.
+func Mount(root *conq.Cmd, at []string, subs ...*conq.Cmd) (*conq.Cmd, error) {
+	if Find(root, at...) == nil {
+		return nil, fmt.Errorf("cannot mount on unknown command %q", strings.Join(at, " "))
+	}
+	return Map(root, At(at, func(_ conq.Pth, cmd *conq.Cmd) *conq.Cmd {
+		cmd.Commands = append(cmd.Commands[:len(cmd.Commands):len(cmd.Commands)], subs...)
+		return cmd
+	})), nil
+}
+
+// Graft returns a copy of the tree with the command named by at replaced by sub.
+func Graft(root *conq.Cmd, at []string, sub *conq.Cmd) (*conq.Cmd, error) {
+	if Find(root, at...) == nil {
+		return nil, fmt.Errorf("cannot graft onto unknown command %q", strings.Join(at, " "))
+	}
+	return Map(root, At(at, func(conq.Pth, *conq.Cmd) *conq.Cmd {
+		return sub
+	})), nil
+}
@@ -0,0 +1,119 @@
+package tree_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/patroclos/go-conq"
+	"github.com/patroclos/go-conq/commander"
+	"github.com/patroclos/go-conq/getopt"
+	"github.com/patroclos/go-conq/tree"
+)
+
+func makeTree() *conq.Cmd {
+	return &conq.Cmd{
+		Name: "app",
+		Commands: []*conq.Cmd{
+			{Name: "foo", Commands: []*conq.Cmd{{Name: "baz"}}},
+			{Name: "bar"},
+		},
+	}
+}
+
+func TestWalkAndFind(t *testing.T) {
+	root := makeTree()
+	var visited []string
+	err := tree.Walk(root, func(pth conq.Pth) error {
+		visited = append(visited, pth[len(pth)-1].Name)
+		if pth[len(pth)-1].Name == "foo" {
+			return tree.SkipCmd
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(visited, " "); got != "app foo bar" {
+		t.Errorf("unexpected walk order %q", got)
+	}
+
+	if pth := tree.Find(root, "foo", "baz"); len(pth) != 3 || pth[2].Name != "baz" {
+		t.Errorf("failed finding foo baz: %v", pth)
+	}
+	if pth := tree.Find(root, "foo", "nope"); pth != nil {
+		t.Errorf("expected nil path for unknown command, got %v", pth)
+	}
+}
+
+func TestMountDoesNotMutate(t *testing.T) {
+	root := makeTree()
+	mounted, err := tree.Mount(root, []string{"foo", "baz"}, &conq.Cmd{Name: "qux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Find(mounted, "foo", "baz", "qux") == nil {
+		t.Error("mounted command not found")
+	}
+	if tree.Find(root, "foo", "baz", "qux") != nil {
+		t.Error("original tree was modified")
+	}
+
+	if _, err := tree.Mount(root, []string{"nope"}); err == nil {
+		t.Error("expected error mounting on unknown command")
+	}
+}
+
+func TestAddCommands(t *testing.T) {
+	help := &conq.Cmd{Name: "help"}
+	root := tree.Map(makeTree(), tree.AddCommands(help))
+	tree.Walk(root, func(pth conq.Pth) error {
+		cmd := pth[len(pth)-1]
+		if cmd == help {
+			return nil
+		}
+		var n int
+		for _, sub := range cmd.Commands {
+			if sub.Name == "help" {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Errorf("expected one help command on %q, got %d", cmd.Name, n)
+		}
+		return nil
+	})
+}
+
+func TestDeprecateAndPrefixEnv(t *testing.T) {
+	envFoo := conq.ReqOpt[string]{Name: "FOO"}
+	var got string
+	root := makeTree()
+	root.Commands[1].Env = conq.Opts{envFoo}
+	root.Commands[1].Run = func(c conq.Ctx) error {
+		got = envFoo.Get(c)
+		return nil
+	}
+
+	root = tree.Map(root, tree.Chain(
+		tree.PrefixEnv("APP_"),
+		tree.At([]string{"bar"}, tree.Deprecate("use foo")),
+	))
+
+	os.Setenv("APP_FOO", "prefixed")
+	defer os.Unsetenv("APP_FOO")
+
+	var errBuf bytes.Buffer
+	ctx := conq.OSContext("bar")
+	ctx.Err = &errBuf
+	if err := commander.New(getopt.New(), nil).Execute(root, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got != "prefixed" {
+		t.Errorf("expected value of APP_FOO, got %q", got)
+	}
+	if !strings.Contains(errBuf.String(), `"app bar" is deprecated: use foo`) {
+		t.Errorf("missing deprecation warning, got %q", errBuf.String())
+	}
+}
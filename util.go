@@ -1,9 +1,12 @@
 package conq
 
 import (
+	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/Xuanwo/go-locale"
 	"golang.org/x/text/language"
@@ -29,25 +32,93 @@ func OSContext(args ...string) Ctx {
 	if len(args) == 0 {
 		args = os.Args[1:]
 	}
+	langs := ctxLanguages()
+	names := make([]string, len(langs))
+	for i, t := range langs {
+		names[i] = t.String()
+	}
 	return Ctx{
 		In:      os.Stdin,
 		Out:     os.Stdout,
 		Err:     os.Stderr,
 		Args:    args,
-		Printer: ctxPrinter(),
+		Printer: message.NewPrinter(message.MatchLanguage(names...)),
+		Langs:   langs,
 	}
 }
 
-func ctxPrinter() *message.Printer {
+// ctxLanguages returns the languages configured in the environment, most
+// preferred first.
+func ctxLanguages() []language.Tag {
 	tags, err := locale.DetectAll()
-	if err != nil {
+	if err != nil || len(tags) == 0 {
 		log.Println("fallback english")
-		return message.NewPrinter(language.English)
+		return []language.Tag{language.English}
 	}
-	names := make([]string, len(tags))
-	for i, t := range tags {
-		names[i] = t.String()
+	return tags
+}
+
+// TermSize returns the width and height of the terminal f refers to.  It only
+// succeeds when f has a `Fd() uintptr` method referring to a terminal.  The
+// width falls back to the COLUMNS environment variable.
+func TermSize(f interface{}) (width, height int, ok bool) {
+	if fd, isFile := f.(interface{ Fd() uintptr }); isFile && IsTerm(f) {
+		if width, height, ok = termSize(fd.Fd()); ok {
+			return
+		}
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols, 0, true
+	}
+	return 0, 0, false
+}
+
+// ColorMode is a policy for emitting colored output.  It implements
+// encoding.TextUnmarshaler for use in options (auto, always or never).
+type ColorMode int
+
+const (
+	// ColorAuto colors output written to terminals, unless the NO_COLOR
+	// environment variable is set to a non-empty value.
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+func (m ColorMode) String() string {
+	switch m {
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	}
+	return "auto"
+}
+
+func (m *ColorMode) UnmarshalText(txt []byte) error {
+	switch string(txt) {
+	case "auto":
+		*m = ColorAuto
+	case "always":
+		*m = ColorAlways
+	case "never":
+		*m = ColorNever
+	default:
+		return fmt.Errorf("invalid color mode %q, expected auto, always or never", txt)
+	}
+	return nil
+}
+
+// Enabled reports whether output written to w should be colored.
+func (m ColorMode) Enabled(w io.Writer) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
 	}
-	match := message.MatchLanguage(names...)
-	return message.NewPrinter(match)
+	return IsTerm(w)
 }
@@ -0,0 +1,7 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package conq
+
+func termSize(fd uintptr) (width, height int, ok bool) {
+	return 0, 0, false
+}